@@ -49,6 +49,20 @@ func LeUint32(p []byte) (ret uint32) {
 func LeUint16(p []byte) (ret uint16) {
 	return binary.LittleEndian.Uint16(p)
 }
+
+func LeUint24(p []byte) uint32 {
+	return uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16
+}
+
+func LeUint64(p []byte) (ret uint64) {
+	return binary.LittleEndian.Uint64(p)
+}
+
+func LeFloat64(p []byte) (ret float64) {
+	a := binary.LittleEndian.Uint64(p)
+	return math.Float64frombits(a)
+}
+
 func ReadBytes(r io.Reader, n int) ([]byte, error) {
 	b := make([]byte, n)
 	// 原生Read函数，读不够时，会在第一次调用时读入剩余的数据，并返回读入数据的真实长度，以及nil值的error
@@ -150,6 +164,17 @@ func LePutUint32(out []byte, in uint32) {
 func LePutUint16(out []byte, in uint16) {
 	binary.LittleEndian.PutUint16(out, in)
 }
+
+func LePutUint24(out []byte, in uint32) {
+	out[0] = byte(in)
+	out[1] = byte(in >> 8)
+	out[2] = byte(in >> 16)
+}
+
+func LePutUint64(out []byte, in uint64) {
+	binary.LittleEndian.PutUint64(out, in)
+}
+
 func WriteBeUint24(writer io.Writer, in uint32) error {
 	_, err := writer.Write([]byte{uint8(in >> 16), uint8(in >> 8), uint8(in & 0xFF)})
 	return err
@@ -131,6 +131,59 @@ func TestLeUint16(t *testing.T) {
 		assert.Equal(t, vector[i].output, LeUint16(vector[i].input))
 	}
 }
+func TestLeUint24(t *testing.T) {
+	vector := []struct {
+		input  []byte
+		output uint32
+	}{
+		{input: []byte{0, 0, 0}, output: 0},
+		{input: []byte{1, 0, 0}, output: 1},
+		{input: []byte{0, 1, 0}, output: 256},
+		{input: []byte{0, 0, 1}, output: 1 * 256 * 256},
+		{input: []byte{12, 34, 56}, output: 12 + 34*256 + 56*256*256},
+	}
+
+	for i := 0; i < len(vector); i++ {
+		assert.Equal(t, vector[i].output, LeUint24(vector[i].input))
+	}
+}
+
+func TestLeUint64(t *testing.T) {
+	vector := []struct {
+		input  []byte
+		output uint64
+	}{
+		{input: []byte{0, 0, 0, 0, 0, 0, 0, 0}, output: 0},
+		{input: []byte{1, 0, 0, 0, 0, 0, 0, 0}, output: 1},
+		{input: []byte{0, 0, 0, 1, 0, 0, 0, 0}, output: 1 * 256 * 256 * 256},
+		{input: []byte{1, 2, 3, 4, 5, 6, 7, 8}, output: 0x0807060504030201},
+	}
+
+	for i := 0; i < len(vector); i++ {
+		assert.Equal(t, vector[i].output, LeUint64(vector[i].input))
+	}
+}
+
+func TestLeFloat64(t *testing.T) {
+	vector := []float64{
+		1,
+		-0.5,
+		0xFFFFFF,
+	}
+	for i := 0; i < len(vector); i++ {
+		b := &bytes.Buffer{}
+		err := binary.Write(b, binary.LittleEndian, vector[i])
+		assert.Equal(t, nil, err)
+		assert.Equal(t, vector[i], LeFloat64(b.Bytes()))
+	}
+}
+
+func TestLePutUint64(t *testing.T) {
+	out := make([]byte, 8)
+	LePutUint64(out, 0x0807060504030201)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, out)
+}
+
 func TestBePutUint16(t *testing.T) {
 	b := make([]byte, 2)
 	BePutUint16(b, 1)
@@ -216,6 +269,25 @@ func TestLePutUint16(t *testing.T) {
 		assert.Equal(t, vector[i].output, out)
 	}
 }
+func TestLePutUint24(t *testing.T) {
+	vector := []struct {
+		input  uint32
+		output []byte
+	}{
+		{input: 0, output: []byte{0, 0, 0}},
+		{input: 1 * 256 * 256, output: []byte{0, 0, 1}},
+		{input: 1 * 256, output: []byte{0, 1, 0}},
+		{input: 1, output: []byte{1, 0, 0}},
+		{input: 56*256*256 + 34*256 + 12, output: []byte{12, 34, 56}},
+	}
+
+	out := make([]byte, 3)
+	for i := 0; i < len(vector); i++ {
+		LePutUint24(out, vector[i].input)
+		assert.Equal(t, vector[i].output, out)
+	}
+}
+
 func TestWriteBeUint24(t *testing.T) {
 	vector := []struct {
 		input  uint32
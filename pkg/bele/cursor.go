@@ -0,0 +1,295 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package bele
+
+import "math"
+
+// Reader 在字节切片上顺序读取，自动维护读取位置
+//
+// 数据不够时不会panic，而是返回 ErrBele ，并且之后的读取都返回该错误。
+// 所以可以在每次读取后判断是否发生错误，也可以在多次读取后，通过 Err 判断是否发生错误。
+//
+// 整个读取过程不申请内存
+type Reader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func NewReader(b []byte) Reader {
+	return Reader{
+		b: b,
+	}
+}
+
+func (r *Reader) ReadUint8() (uint8, error) {
+	p := r.next(1)
+	if p == nil {
+		return 0, r.err
+	}
+	return p[0], nil
+}
+
+func (r *Reader) ReadBeUint16() (uint16, error) {
+	p := r.next(2)
+	if p == nil {
+		return 0, r.err
+	}
+	return BeUint16(p), nil
+}
+
+func (r *Reader) ReadBeUint24() (uint32, error) {
+	p := r.next(3)
+	if p == nil {
+		return 0, r.err
+	}
+	return BeUint24(p), nil
+}
+
+func (r *Reader) ReadBeUint32() (uint32, error) {
+	p := r.next(4)
+	if p == nil {
+		return 0, r.err
+	}
+	return BeUint32(p), nil
+}
+
+func (r *Reader) ReadBeUint64() (uint64, error) {
+	p := r.next(8)
+	if p == nil {
+		return 0, r.err
+	}
+	return BeUint64(p), nil
+}
+
+func (r *Reader) ReadBeFloat64() (float64, error) {
+	p := r.next(8)
+	if p == nil {
+		return 0, r.err
+	}
+	return BeFloat64(p), nil
+}
+
+func (r *Reader) ReadLeUint16() (uint16, error) {
+	p := r.next(2)
+	if p == nil {
+		return 0, r.err
+	}
+	return LeUint16(p), nil
+}
+
+func (r *Reader) ReadLeUint24() (uint32, error) {
+	p := r.next(3)
+	if p == nil {
+		return 0, r.err
+	}
+	return LeUint24(p), nil
+}
+
+func (r *Reader) ReadLeUint32() (uint32, error) {
+	p := r.next(4)
+	if p == nil {
+		return 0, r.err
+	}
+	return LeUint32(p), nil
+}
+
+func (r *Reader) ReadLeUint64() (uint64, error) {
+	p := r.next(8)
+	if p == nil {
+		return 0, r.err
+	}
+	return LeUint64(p), nil
+}
+
+func (r *Reader) ReadLeFloat64() (float64, error) {
+	p := r.next(8)
+	if p == nil {
+		return 0, r.err
+	}
+	return LeFloat64(p), nil
+}
+
+// ReadBytes
+//
+// @return 注意，返回的是底层切片的一部分，没有拷贝
+func (r *Reader) ReadBytes(n int) ([]byte, error) {
+	p := r.next(n)
+	if p == nil {
+		return nil, r.err
+	}
+	return p, nil
+}
+
+func (r *Reader) Skip(n int) error {
+	if r.next(n) == nil {
+		return r.err
+	}
+	return nil
+}
+
+// Remaining 还没有读取的字节数
+func (r *Reader) Remaining() int {
+	return len(r.b) - r.off
+}
+
+// Offset 已经读取的字节数，也即下一个待读取字节的下标
+func (r *Reader) Offset() int {
+	return r.off
+}
+
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// next 获取接下来的`n`个字节，数据不够时返回nil
+func (r *Reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.b)-r.off {
+		r.err = ErrBele
+		return nil
+	}
+	p := r.b[r.off : r.off+n : r.off+n]
+	r.off += n
+	return p
+}
+
+// ----------------------------------------------------------------------------
+
+// Writer 在字节切片上顺序写入，自动维护写入位置
+//
+// 空间不够时不会panic，而是记录 ErrBele ，并且之后的写入都被忽略，在多次写入后，通过 Err 判断是否发生错误。
+//
+// 整个写入过程不申请内存
+type Writer struct {
+	b   []byte
+	off int
+	err error
+}
+
+// NewWriter
+//
+// @param b: 在`b`上写入，`b`的长度即为最多可写入的字节数
+func NewWriter(b []byte) Writer {
+	return Writer{
+		b: b,
+	}
+}
+
+func (w *Writer) WriteUint8(v uint8) {
+	if p := w.next(1); p != nil {
+		p[0] = v
+	}
+}
+
+func (w *Writer) WriteBeUint16(v uint16) {
+	if p := w.next(2); p != nil {
+		BePutUint16(p, v)
+	}
+}
+
+func (w *Writer) WriteBeUint24(v uint32) {
+	if p := w.next(3); p != nil {
+		BePutUint24(p, v)
+	}
+}
+
+func (w *Writer) WriteBeUint32(v uint32) {
+	if p := w.next(4); p != nil {
+		BePutUint32(p, v)
+	}
+}
+
+func (w *Writer) WriteBeUint64(v uint64) {
+	if p := w.next(8); p != nil {
+		BePutUint64(p, v)
+	}
+}
+
+func (w *Writer) WriteBeFloat64(v float64) {
+	if p := w.next(8); p != nil {
+		BePutUint64(p, math.Float64bits(v))
+	}
+}
+
+func (w *Writer) WriteLeUint16(v uint16) {
+	if p := w.next(2); p != nil {
+		LePutUint16(p, v)
+	}
+}
+
+func (w *Writer) WriteLeUint24(v uint32) {
+	if p := w.next(3); p != nil {
+		LePutUint24(p, v)
+	}
+}
+
+func (w *Writer) WriteLeUint32(v uint32) {
+	if p := w.next(4); p != nil {
+		LePutUint32(p, v)
+	}
+}
+
+func (w *Writer) WriteLeUint64(v uint64) {
+	if p := w.next(8); p != nil {
+		LePutUint64(p, v)
+	}
+}
+
+func (w *Writer) WriteLeFloat64(v float64) {
+	if p := w.next(8); p != nil {
+		LePutUint64(p, math.Float64bits(v))
+	}
+}
+
+func (w *Writer) WriteBytes(b []byte) {
+	if p := w.next(len(b)); p != nil {
+		copy(p, b)
+	}
+}
+
+// Skip 跳过`n`个字节，这些字节的内容保持不变，比如先预留长度字段，之后再回填
+func (w *Writer) Skip(n int) {
+	w.next(n)
+}
+
+// Remaining 还可以写入的字节数
+func (w *Writer) Remaining() int {
+	return len(w.b) - w.off
+}
+
+// Offset 已经写入的字节数，也即下一个待写入字节的下标
+func (w *Writer) Offset() int {
+	return w.off
+}
+
+// Bytes 已经写入的数据
+func (w *Writer) Bytes() []byte {
+	return w.b[:w.off]
+}
+
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// next 获取接下来的`n`个字节用于写入，空间不够时返回nil
+func (w *Writer) next(n int) []byte {
+	if w.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(w.b)-w.off {
+		w.err = ErrBele
+		return nil
+	}
+	p := w.b[w.off : w.off+n]
+	w.off += n
+	return p
+}
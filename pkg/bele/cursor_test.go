@@ -0,0 +1,164 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package bele
+
+import (
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+)
+
+func writeAll(w *Writer) {
+	w.WriteUint8(1)
+	w.WriteBeUint16(0x0203)
+	w.WriteBeUint24(0x040506)
+	w.WriteBeUint32(0x0708090A)
+	w.WriteBeUint64(0x0B0C0D0E0F101112)
+	w.WriteBeFloat64(1.5)
+	w.WriteLeUint16(0x0203)
+	w.WriteLeUint24(0x040506)
+	w.WriteLeUint32(0x0708090A)
+	w.WriteLeUint64(0x0B0C0D0E0F101112)
+	w.WriteLeFloat64(-2.25)
+	w.Skip(2)
+	w.WriteBytes([]byte("naza"))
+}
+
+const writeAllSize = 1 + 2 + 3 + 4 + 8 + 8 + 2 + 3 + 4 + 8 + 8 + 2 + 4
+
+func TestReaderWriter(t *testing.T) {
+	b := make([]byte, writeAllSize+1)
+	w := NewWriter(b)
+	writeAll(&w)
+	assert.Equal(t, nil, w.Err())
+	assert.Equal(t, writeAllSize, w.Offset())
+	assert.Equal(t, 1, w.Remaining())
+	assert.Equal(t, b[:writeAllSize], w.Bytes())
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, b[:10])
+	assert.Equal(t, []byte{3, 2, 6, 5, 4, 10, 9, 8, 7}, b[26:35])
+
+	r := NewReader(b)
+	u8, err := r.ReadUint8()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint8(1), u8)
+	u16, err := r.ReadBeUint16()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint16(0x0203), u16)
+	u24, err := r.ReadBeUint24()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint32(0x040506), u24)
+	u32, err := r.ReadBeUint32()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint32(0x0708090A), u32)
+	u64, err := r.ReadBeUint64()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(0x0B0C0D0E0F101112), u64)
+	f64, err := r.ReadBeFloat64()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1.5, f64)
+	u16, err = r.ReadLeUint16()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint16(0x0203), u16)
+	u24, err = r.ReadLeUint24()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint32(0x040506), u24)
+	u32, err = r.ReadLeUint32()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint32(0x0708090A), u32)
+	u64, err = r.ReadLeUint64()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(0x0B0C0D0E0F101112), u64)
+	f64, err = r.ReadLeFloat64()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, -2.25, f64)
+	assert.Equal(t, nil, r.Skip(2))
+	bs, err := r.ReadBytes(4)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []byte("naza"), bs)
+	assert.Equal(t, writeAllSize, r.Offset())
+	assert.Equal(t, 1, r.Remaining())
+
+	// 数据不够时，错误会一直保留，读取位置不变
+	_, err = r.ReadBeUint16()
+	assert.Equal(t, ErrBele, err)
+	_, err = r.ReadUint8()
+	assert.Equal(t, ErrBele, err)
+	assert.Equal(t, ErrBele, r.Err())
+	assert.Equal(t, writeAllSize, r.Offset())
+
+	r = NewReader(b)
+	assert.Equal(t, ErrBele, r.Skip(-1))
+	r = NewReader(nil)
+	_, err = r.ReadBytes(1)
+	assert.Equal(t, ErrBele, err)
+}
+
+func TestWriter_Short(t *testing.T) {
+	for i := 0; i < writeAllSize; i++ {
+		b := make([]byte, i)
+		w := NewWriter(b)
+		writeAll(&w)
+		assert.Equal(t, ErrBele, w.Err())
+		assert.Equal(t, true, w.Offset() <= i)
+	}
+
+	w := NewWriter(make([]byte, 4))
+	w.WriteBeUint16(1)
+	w.WriteBeUint24(2)
+	// 写入失败后，之后的写入都被忽略
+	w.WriteBeUint16(3)
+	assert.Equal(t, ErrBele, w.Err())
+	assert.Equal(t, []byte{0, 1}, w.Bytes())
+	assert.Equal(t, 2, w.Remaining())
+}
+
+func TestReaderWriter_Alloc(t *testing.T) {
+	b := make([]byte, writeAllSize)
+	n := testing.AllocsPerRun(100, func() {
+		w := NewWriter(b)
+		writeAll(&w)
+		r := NewReader(w.Bytes())
+		_, _ = r.ReadBeUint64()
+		_, _ = r.ReadLeFloat64()
+		_, _ = r.ReadBytes(4)
+		_ = r.Skip(100)
+	})
+	assert.Equal(t, float64(0), n)
+}
+
+func BenchmarkReader(b *testing.B) {
+	b.ReportAllocs()
+	buf := make([]byte, writeAllSize)
+	var u32 uint32
+	var u64 uint64
+	for i := 0; i < b.N; i++ {
+		r := NewReader(buf)
+		_, _ = r.ReadUint8()
+		_, _ = r.ReadBeUint16()
+		u32, _ = r.ReadBeUint24()
+		u32, _ = r.ReadBeUint32()
+		u64, _ = r.ReadBeUint64()
+		_, _ = r.ReadBeFloat64()
+		_, _ = r.ReadLeUint16()
+		u32, _ = r.ReadLeUint24()
+		u32, _ = r.ReadLeUint32()
+		u64, _ = r.ReadLeUint64()
+		_, _ = r.ReadLeFloat64()
+	}
+	_, _ = u32, u64
+}
+
+func BenchmarkWriter(b *testing.B) {
+	b.ReportAllocs()
+	buf := make([]byte, writeAllSize)
+	for i := 0; i < b.N; i++ {
+		w := NewWriter(buf)
+		writeAll(&w)
+	}
+}
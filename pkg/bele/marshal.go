@@ -0,0 +1,589 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package bele
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/q191201771/naza/pkg/nazabits"
+)
+
+// 通过结构体字段的tag描述二进制格式，使用 Marshal 和 Unmarshal 序列化和反序列化。
+//
+// 字段按声明顺序依次编码，中间没有填充。tag的key为`bele`，多个选项之间用逗号分隔：
+//
+//   be        大端，默认值
+//   le        小端。对结构体类型的字段，作为其内部字段的默认字节序
+//   size=N    整型编码的字节数，比如uint32使用size=3表示24位，uint64使用size=5表示40位
+//   bits=N    位域，占用N个bit，连续的位域字段从高位向低位打包，总长度必须是8的整数倍
+//   len=N     string或[]byte的固定长度
+//   prefix=N  string或[]byte前面有一个N字节的长度字段，字节序和字段相同
+//   rest      string或[]byte使用剩下的所有数据，只能是最后一个字段
+//   -         忽略该字段
+//
+// 支持的字段类型：bool，int8~int64，uint8~uint64，float32，float64，string，[]byte，数组，结构体。
+// 不导出的字段会被忽略。
+//
+// 比如RTP固定头：
+//
+//   type RtpHeader struct {
+//       Version     uint8  `bele:"bits=2"`
+//       Padding     bool   `bele:"bits=1"`
+//       Extension   bool   `bele:"bits=1"`
+//       CsrcCount   uint8  `bele:"bits=4"`
+//       Mark        bool   `bele:"bits=1"`
+//       PayloadType uint8  `bele:"bits=7"`
+//       Seq         uint16
+//       Timestamp   uint32
+//       Ssrc        uint32
+//   }
+
+var ErrBele = errors.New("naza.bele: fxxk")
+
+const tagKey = "bele"
+
+// Marshal 将结构体按tag描述的格式序列化
+//
+// @param v: 结构体，或者结构体指针
+//
+// @return 新申请的内存块
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: marshal non-struct %T", ErrBele, v)
+	}
+	// 保证可寻址，方便读取数组的内容
+	if !rv.CanAddr() {
+		nv := reflect.New(rv.Type()).Elem()
+		nv.Set(rv)
+		rv = nv
+	}
+
+	var e encoder
+	if err := e.encodeStruct(rv, false); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// Unmarshal 将`b`按tag描述的格式反序列化到结构体中
+//
+// `b`的长度可以大于需要的长度，比如协议头后面跟着负载的情况
+//
+// @param v: 结构体指针
+//
+// @return n: 使用了`b`中多少个字节
+func Unmarshal(b []byte, v interface{}) (n int, err error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return 0, fmt.Errorf("%w: unmarshal into non-struct-pointer %T", ErrBele, v)
+	}
+
+	d := decoder{b: b}
+	if err = d.decodeStruct(rv.Elem(), false); err != nil {
+		return 0, err
+	}
+	return d.off, nil
+}
+
+// ----------------------------------------------------------------------------
+
+const (
+	orderInherit uint8 = iota
+	orderBe
+	orderLe
+)
+
+type fieldInfo struct {
+	index    int
+	name     string
+	order    uint8
+	size     int  // 整型编码的字节数，0表示使用类型本身的大小
+	bits     uint // 位域的bit数，0表示不是位域
+	runBits  uint // 一组连续位域的总bit数，只记录在这组位域的第一个字段上
+	fixedLen int  // -1表示没有设置
+	prefix   int
+	rest     bool
+}
+
+func (f *fieldInfo) isLe(parentLe bool) bool {
+	switch f.order {
+	case orderBe:
+		return false
+	case orderLe:
+		return true
+	}
+	return parentLe
+}
+
+type structInfo struct {
+	fields []fieldInfo
+	err    error
+}
+
+var structInfoCache sync.Map // map[reflect.Type]*structInfo
+
+func getStructInfo(t reflect.Type) ([]fieldInfo, error) {
+	if si, ok := structInfoCache.Load(t); ok {
+		return si.(*structInfo).fields, si.(*structInfo).err
+	}
+	fields, err := parseStruct(t)
+	structInfoCache.Store(t, &structInfo{fields: fields, err: err})
+	return fields, err
+}
+
+func parseStruct(t reflect.Type) ([]fieldInfo, error) {
+	var fields []fieldInfo
+	runStart := -1
+	closeRun := func() error {
+		if runStart == -1 {
+			return nil
+		}
+		if fields[runStart].runBits%8 != 0 {
+			return fmt.Errorf("%w: %s.%s: bit fields total %d bits, not byte aligned",
+				ErrBele, t.Name(), fields[runStart].name, fields[runStart].runBits)
+		}
+		runStart = -1
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get(tagKey)
+		if sf.PkgPath != "" || tag == "-" {
+			continue
+		}
+		f, err := parseTag(tag)
+		if err == nil {
+			f.index = i
+			f.name = sf.Name
+			err = checkField(sf.Type, &f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s.%s: %s", ErrBele, t.Name(), sf.Name, err.Error())
+		}
+
+		if f.bits == 0 {
+			if err = closeRun(); err != nil {
+				return nil, err
+			}
+		} else if runStart == -1 {
+			runStart = len(fields)
+		}
+		fields = append(fields, f)
+		if runStart != -1 {
+			fields[runStart].runBits += f.bits
+		}
+	}
+	if err := closeRun(); err != nil {
+		return nil, err
+	}
+
+	for i := range fields {
+		if fields[i].rest && i != len(fields)-1 {
+			return nil, fmt.Errorf("%w: %s.%s: rest must be the last field", ErrBele, t.Name(), fields[i].name)
+		}
+	}
+	return fields, nil
+}
+
+func parseTag(tag string) (f fieldInfo, err error) {
+	f.fixedLen = -1
+	if tag == "" {
+		return
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+		key, value := opt, ""
+		if i := strings.IndexByte(opt, '='); i != -1 {
+			key, value = opt[:i], opt[i+1:]
+		}
+		var n int
+		if value != "" {
+			if n, err = strconv.Atoi(value); err != nil || n <= 0 {
+				return f, fmt.Errorf("invalid tag option %q", opt)
+			}
+		}
+		switch {
+		case opt == "be":
+			f.order = orderBe
+		case opt == "le":
+			f.order = orderLe
+		case opt == "rest":
+			f.rest = true
+		case key == "size" && n > 0:
+			f.size = n
+		case key == "bits" && n > 0:
+			f.bits = uint(n)
+		case key == "len" && n > 0:
+			f.fixedLen = n
+		case key == "prefix" && n > 0:
+			f.prefix = n
+		default:
+			return f, fmt.Errorf("invalid tag option %q", opt)
+		}
+	}
+	return
+}
+
+// checkField 检查tag选项和字段类型是否匹配
+func checkField(t reflect.Type, f *fieldInfo) error {
+	isBytes := t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+	if !isBytes && (f.fixedLen != -1 || f.prefix != 0 || f.rest) {
+		return errors.New("len, prefix and rest only apply to string or []byte")
+	}
+	if f.bits != 0 && f.size != 0 {
+		return errors.New("bits and size are exclusive")
+	}
+
+	if f.bits != 0 {
+		switch t.Kind() {
+		case reflect.Bool:
+			if f.bits != 1 {
+				return errors.New("bool bit field must be bits=1")
+			}
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if f.bits > uint(t.Bits()) {
+				return fmt.Errorf("bits=%d exceeds %s", f.bits, t.Kind())
+			}
+		default:
+			return fmt.Errorf("bit field of unsupported type %s", t.Kind())
+		}
+		return nil
+	}
+
+	if isBytes {
+		n := 0
+		if f.fixedLen != -1 {
+			n++
+		}
+		if f.prefix != 0 {
+			n++
+		}
+		if f.rest {
+			n++
+		}
+		if n != 1 {
+			return errors.New("string or []byte needs exactly one of len, prefix and rest")
+		}
+		if f.prefix > 8 {
+			return fmt.Errorf("prefix=%d exceeds 8", f.prefix)
+		}
+		return nil
+	}
+
+	// 整型的数组，size作用于每个元素
+	elem := t
+	for elem.Kind() == reflect.Array {
+		elem = elem.Elem()
+	}
+	switch elem.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f.size*8 > elem.Bits() {
+			return fmt.Errorf("size=%d exceeds %s", f.size, elem.Kind())
+		}
+		return nil
+	case reflect.Bool, reflect.Float32, reflect.Float64, reflect.Struct:
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	if f.size != 0 {
+		return fmt.Errorf("size on non-integer type %s", t)
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) encodeStruct(v reflect.Value, le bool) error {
+	fields, err := getStructInfo(v.Type())
+	if err != nil {
+		return err
+	}
+
+	var bw nazabits.BitWriter
+	for i := range fields {
+		f := &fields[i]
+		fv := v.Field(f.index)
+		if f.bits == 0 {
+			if err = e.encodeValue(fv, f, f.isLe(le)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if f.runBits != 0 {
+			start := len(e.buf)
+			e.buf = append(e.buf, make([]byte, f.runBits/8)...)
+			bw = nazabits.NewBitWriter(e.buf[start:])
+		}
+		var u uint64
+		if fv.Kind() == reflect.Bool {
+			if fv.Bool() {
+				u = 1
+			}
+		} else {
+			u = fv.Uint()
+		}
+		if f.bits < 64 && u>>f.bits != 0 {
+			return fmt.Errorf("%w: %s: value %d overflows %d bits", ErrBele, f.name, u, f.bits)
+		}
+		bw.WriteBits64(f.bits, u)
+	}
+	return nil
+}
+
+func (e *encoder) encodeValue(v reflect.Value, f *fieldInfo, le bool) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 1)
+		} else {
+			e.buf = append(e.buf, 0)
+		}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size := intSize(v, f)
+		u := v.Uint()
+		if size < 8 && u>>(8*uint(size)) != 0 {
+			return fmt.Errorf("%w: %s: value %d overflows %d bytes", ErrBele, f.name, u, size)
+		}
+		e.putUint(u, size, le)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size := intSize(v, f)
+		i := v.Int()
+		if size < 8 {
+			shift := 64 - 8*uint(size)
+			if i<<shift>>shift != i {
+				return fmt.Errorf("%w: %s: value %d overflows %d bytes", ErrBele, f.name, i, size)
+			}
+		}
+		e.putUint(uint64(i), size, le)
+	case reflect.Float32:
+		e.putUint(uint64(math.Float32bits(float32(v.Float()))), 4, le)
+	case reflect.Float64:
+		e.putUint(math.Float64bits(v.Float()), 8, le)
+	case reflect.String:
+		return e.encodeBytes([]byte(v.String()), f, le)
+	case reflect.Slice:
+		return e.encodeBytes(v.Bytes(), f, le)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.buf = append(e.buf, v.Slice(0, v.Len()).Bytes()...)
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encodeValue(v.Index(i), f, le); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return e.encodeStruct(v, le)
+	}
+	return nil
+}
+
+func (e *encoder) encodeBytes(b []byte, f *fieldInfo, le bool) error {
+	if f.fixedLen != -1 && len(b) != f.fixedLen {
+		return fmt.Errorf("%w: %s: length %d, expect %d", ErrBele, f.name, len(b), f.fixedLen)
+	}
+	if f.prefix != 0 {
+		if f.prefix < 8 && uint64(len(b))>>(8*uint(f.prefix)) != 0 {
+			return fmt.Errorf("%w: %s: length %d overflows %d bytes prefix", ErrBele, f.name, len(b), f.prefix)
+		}
+		e.putUint(uint64(len(b)), f.prefix, le)
+	}
+	e.buf = append(e.buf, b...)
+	return nil
+}
+
+func (e *encoder) putUint(u uint64, size int, le bool) {
+	for i := 0; i < size; i++ {
+		if le {
+			e.buf = append(e.buf, byte(u>>(8*uint(i))))
+		} else {
+			e.buf = append(e.buf, byte(u>>(8*uint(size-1-i))))
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+type decoder struct {
+	b   []byte
+	off int
+}
+
+func (d *decoder) decodeStruct(v reflect.Value, le bool) error {
+	fields, err := getStructInfo(v.Type())
+	if err != nil {
+		return err
+	}
+
+	var br nazabits.BitReader
+	for i := range fields {
+		f := &fields[i]
+		fv := v.Field(f.index)
+		if f.bits == 0 {
+			if err = d.decodeValue(fv, f, f.isLe(le)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if f.runBits != 0 {
+			p, err := d.next(int(f.runBits/8), f)
+			if err != nil {
+				return err
+			}
+			br = nazabits.NewBitReader(p)
+		}
+		u, _ := br.ReadBits64(f.bits)
+		if fv.Kind() == reflect.Bool {
+			fv.SetBool(u != 0)
+		} else {
+			fv.SetUint(u)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeValue(v reflect.Value, f *fieldInfo, le bool) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		p, err := d.next(1, f)
+		if err != nil {
+			return err
+		}
+		v.SetBool(p[0] != 0)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size := intSize(v, f)
+		u, err := d.getUint(size, f, le)
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size := intSize(v, f)
+		u, err := d.getUint(size, f, le)
+		if err != nil {
+			return err
+		}
+		// 符号扩展
+		shift := 64 - 8*uint(size)
+		v.SetInt(int64(u<<shift) >> shift)
+	case reflect.Float32:
+		u, err := d.getUint(4, f, le)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(uint32(u))))
+	case reflect.Float64:
+		u, err := d.getUint(8, f, le)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(u))
+	case reflect.String:
+		p, err := d.nextBytes(f, le)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(p))
+	case reflect.Slice:
+		p, err := d.nextBytes(f, le)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(append([]byte{}, p...))
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			p, err := d.next(v.Len(), f)
+			if err != nil {
+				return err
+			}
+			reflect.Copy(v, reflect.ValueOf(p))
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := d.decodeValue(v.Index(i), f, le); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return d.decodeStruct(v, le)
+	}
+	return nil
+}
+
+func (d *decoder) nextBytes(f *fieldInfo, le bool) ([]byte, error) {
+	switch {
+	case f.fixedLen != -1:
+		return d.next(f.fixedLen, f)
+	case f.prefix != 0:
+		n, err := d.getUint(f.prefix, f, le)
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(d.b)-d.off) {
+			return nil, d.errShort(f)
+		}
+		return d.next(int(n), f)
+	}
+	return d.next(len(d.b)-d.off, f)
+}
+
+func (d *decoder) getUint(size int, f *fieldInfo, le bool) (u uint64, err error) {
+	p, err := d.next(size, f)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < size; i++ {
+		if le {
+			u |= uint64(p[i]) << (8 * uint(i))
+		} else {
+			u = u<<8 | uint64(p[i])
+		}
+	}
+	return u, nil
+}
+
+func (d *decoder) next(n int, f *fieldInfo) ([]byte, error) {
+	if len(d.b)-d.off < n {
+		return nil, d.errShort(f)
+	}
+	p := d.b[d.off : d.off+n]
+	d.off += n
+	return p, nil
+}
+
+func (d *decoder) errShort(f *fieldInfo) error {
+	return fmt.Errorf("%w: %s: short buffer, offset %d, length %d", ErrBele, f.name, d.off, len(d.b))
+}
+
+// intSize 整型编码的字节数
+func intSize(v reflect.Value, f *fieldInfo) int {
+	if f.size != 0 {
+		return f.size
+	}
+	return v.Type().Bits() / 8
+}
@@ -0,0 +1,233 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package bele
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+)
+
+type rtpHeader struct {
+	Version     uint8 `bele:"bits=2"`
+	Padding     bool  `bele:"bits=1"`
+	Extension   bool  `bele:"bits=1"`
+	CsrcCount   uint8 `bele:"bits=4"`
+	Mark        bool  `bele:"bits=1"`
+	PayloadType uint8 `bele:"bits=7"`
+	Seq         uint16
+	Timestamp   uint32
+	Ssrc        uint32
+}
+
+type flvTag struct {
+	TagType   uint8
+	DataSize  uint32 `bele:"size=3"`
+	Timestamp uint32 `bele:"size=3"`
+	TsExt     uint8
+	StreamId  uint32 `bele:"size=3"`
+	Payload   []byte `bele:"rest"`
+}
+
+type mixed struct {
+	A      int16  `bele:"le"`
+	B      int32  `bele:"size=3"`
+	C      uint64 `bele:"size=5"`
+	F32    float32
+	F64    float64 `bele:"le"`
+	Ok     bool
+	Magic  [4]byte
+	Name   string    `bele:"len=3"`
+	Msg    string    `bele:"prefix=2,le"`
+	Arr    [2]uint32 `bele:"size=3"`
+	Inner  inner     `bele:"le"`
+	Skip   int       `bele:"-"`
+	hidden int
+	Data   []byte `bele:"prefix=1"`
+}
+
+type inner struct {
+	X uint16
+	Y uint16 `bele:"be"`
+}
+
+func TestMarshal_Rtp(t *testing.T) {
+	h := rtpHeader{
+		Version:     2,
+		Mark:        true,
+		PayloadType: 96,
+		Seq:         0x1234,
+		Timestamp:   0x11223344,
+		Ssrc:        0xAABBCCDD,
+	}
+	b, err := Marshal(h)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []byte{0x80, 0xE0, 0x12, 0x34, 0x11, 0x22, 0x33, 0x44, 0xAA, 0xBB, 0xCC, 0xDD}, b)
+
+	var h2 rtpHeader
+	n, err := Unmarshal(append(b, 0xFF, 0xFF), &h2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 12, n)
+	assert.Equal(t, h, h2)
+}
+
+func TestMarshal_Flv(t *testing.T) {
+	b := []byte{9, 0, 0, 3, 0, 1, 2, 3, 0, 0, 0, 0x17, 0x01, 0x00}
+	var tag flvTag
+	n, err := Unmarshal(b, &tag)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(b), n)
+	assert.Equal(t, uint8(9), tag.TagType)
+	assert.Equal(t, uint32(3), tag.DataSize)
+	assert.Equal(t, uint32(0x000102), tag.Timestamp)
+	assert.Equal(t, uint8(3), tag.TsExt)
+	assert.Equal(t, []byte{0x17, 0x01, 0x00}, tag.Payload)
+
+	b2, err := Marshal(&tag)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, b, b2)
+}
+
+func TestMarshal_Mixed(t *testing.T) {
+	m := mixed{
+		A:     -2,
+		B:     -0x123456,
+		C:     0xFF12345678,
+		F32:   1.5,
+		F64:   -3.25,
+		Ok:    true,
+		Magic: [4]byte{'n', 'a', 'z', 'a'},
+		Name:  "abc",
+		Msg:   "hello",
+		Arr:   [2]uint32{1, 0xFFFFFF},
+		Inner: inner{X: 0x0102, Y: 0x0304},
+		Skip:  1,
+		Data:  []byte{7, 8},
+	}
+	b, err := Marshal(&m)
+	assert.Equal(t, nil, err)
+	expected := []byte{
+		0xFE, 0xFF, // A
+		0xED, 0xCB, 0xAA, // B
+		0xFF, 0x12, 0x34, 0x56, 0x78, // C
+		0x3F, 0xC0, 0x00, 0x00, // F32
+		0, 0, 0, 0, 0, 0, 0x0A, 0xC0, // F64
+		1,                  // Ok
+		'n', 'a', 'z', 'a', // Magic
+		'a', 'b', 'c', // Name
+		5, 0, 'h', 'e', 'l', 'l', 'o', // Msg
+		0, 0, 1, 0xFF, 0xFF, 0xFF, // Arr
+		0x02, 0x01, 0x03, 0x04, // Inner
+		2, 7, 8, // Data
+	}
+	assert.Equal(t, expected, b)
+
+	var m2 mixed
+	n, err := Unmarshal(b, &m2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(b), n)
+	m.Skip = 0
+	assert.Equal(t, m, m2)
+
+	// 数据不够
+	for i := 0; i < len(b); i++ {
+		_, err = Unmarshal(b[:i], &m2)
+		assert.Equal(t, true, errors.Is(err, ErrBele))
+	}
+}
+
+func TestMarshal_Err(t *testing.T) {
+	_, err := Marshal(1)
+	assert.Equal(t, true, errors.Is(err, ErrBele))
+	var h rtpHeader
+	_, err = Unmarshal(nil, h)
+	assert.Equal(t, true, errors.Is(err, ErrBele))
+
+	// 值超出范围
+	_, err = Marshal(rtpHeader{Version: 4})
+	assert.Equal(t, true, errors.Is(err, ErrBele))
+	_, err = Marshal(flvTag{DataSize: 1 << 24})
+	assert.Equal(t, true, errors.Is(err, ErrBele))
+	_, err = Marshal(struct {
+		A int32 `bele:"size=3"`
+	}{A: 1 << 23})
+	assert.Equal(t, true, errors.Is(err, ErrBele))
+	_, err = Marshal(struct {
+		A string `bele:"len=2"`
+	}{A: "abc"})
+	assert.Equal(t, true, errors.Is(err, ErrBele))
+	_, err = Marshal(struct {
+		A []byte `bele:"prefix=1"`
+	}{A: make([]byte, 256)})
+	assert.Equal(t, true, errors.Is(err, ErrBele))
+
+	// tag错误
+	invalids := []interface{}{
+		struct {
+			A uint8 `bele:"bits=3"`
+		}{},
+		struct {
+			A uint8 `bele:"bits=9"`
+		}{},
+		struct {
+			A int8 `bele:"bits=8"`
+		}{},
+		struct {
+			A uint16 `bele:"size=3"`
+		}{},
+		struct {
+			A float32 `bele:"size=2"`
+		}{},
+		struct {
+			A string
+		}{},
+		struct {
+			A []byte `bele:"len=1,rest"`
+		}{},
+		struct {
+			A []byte `bele:"rest"`
+			B uint8
+		}{},
+		struct {
+			A uint8 `bele:"len=1"`
+		}{},
+		struct {
+			A int
+		}{},
+		struct {
+			A []uint16
+		}{},
+		struct {
+			A uint8 `bele:"xx"`
+		}{},
+		struct {
+			A uint8 `bele:"size=0"`
+		}{},
+	}
+	for _, v := range invalids {
+		_, err = Marshal(v)
+		assert.Equal(t, true, errors.Is(err, ErrBele))
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	h := rtpHeader{Version: 2, PayloadType: 96, Seq: 1, Timestamp: 2, Ssrc: 3}
+	for i := 0; i < b.N; i++ {
+		_, _ = Marshal(&h)
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	buf, _ := Marshal(rtpHeader{Version: 2, PayloadType: 96, Seq: 1, Timestamp: 2, Ssrc: 3})
+	var h rtpHeader
+	for i := 0; i < b.N; i++ {
+		_, _ = Unmarshal(buf, &h)
+	}
+}
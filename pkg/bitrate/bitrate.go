@@ -80,6 +80,13 @@ func (b *bitrate) Add(bytes int, nowUnixMs ...int64) {
 	defer b.mu.Unlock()
 
 	b.sweepStale(now)
+
+	// 时间不晚于最后一个桶的，合并到最后一个桶中，
+	// 这样桶的个数不超过窗口的毫秒数，不会因为 Add 调用频繁而无限增长
+	if l := len(b.bucketSlice); l > 0 && now <= b.bucketSlice[l-1].t {
+		b.bucketSlice[l-1].n += bytes
+		return
+	}
 	b.bucketSlice = append(b.bucketSlice, bucket{
 		n: bytes,
 		t: now,
@@ -56,3 +56,17 @@ func TestOutsizeNow(t *testing.T) {
 	r := b.Rate(now)
 	assert.Equal(t, float32(800), r)
 }
+
+func TestMergeBucket(t *testing.T) {
+	b := bitrate.New()
+	now := time.Now().UnixNano() / 1e6
+	for i := 0; i < 10000; i++ {
+		b.Add(1, now)
+	}
+	// 早于最后一个桶的时间，也合并到最后一个桶中
+	b.Add(1000, now-1)
+	assert.Equal(t, float32(88), b.Rate(now))
+	b.Add(1000, now+500)
+	assert.Equal(t, float32(96), b.Rate(now+1000))
+	assert.Equal(t, float32(8), b.Rate(now+1001))
+}
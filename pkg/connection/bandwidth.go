@@ -0,0 +1,80 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection
+
+import (
+	"time"
+
+	"github.com/q191201771/naza/pkg/ratelimit"
+)
+
+// bandwidthLimiter 基于令牌桶的带宽限制，一个令牌对应一个字节
+type bandwidthLimiter struct {
+	tb       *ratelimit.TokenBucket
+	interval time.Duration
+
+	// 单次从令牌桶中获取的最大令牌数，也即令牌桶的容量
+	chunk int
+}
+
+const bandwidthProdIntervalMs = 10
+
+func newBandwidthLimiter(bytesPerSec int) *bandwidthLimiter {
+	intervalMs := bandwidthProdIntervalMs
+	num := bytesPerSec * intervalMs / 1000
+	if num == 0 {
+		// 速率很低时，拉长生产令牌的间隔，每次只生产一个
+		num = 1
+		intervalMs = 1000 / bytesPerSec
+	}
+
+	// 最多允许100毫秒的突发
+	capacity := bytesPerSec / 10
+	if capacity < num {
+		capacity = num
+	}
+
+	return &bandwidthLimiter{
+		tb:       ratelimit.NewTokenBucket(capacity, intervalMs, num),
+		interval: time.Duration(intervalMs) * time.Millisecond,
+		chunk:    capacity,
+	}
+}
+
+// wait 阻塞直到获取到`n`个字节的额度
+//
+// @param closedChan 关闭时，不再等待，返回 ErrClosedAlready
+func (bl *bandwidthLimiter) wait(n int, closedChan <-chan struct{}) error {
+	var timer *time.Timer
+	for n > 0 {
+		m := n
+		if m > bl.chunk {
+			m = bl.chunk
+		}
+		for bl.tb.TryAquireWithNum(m) != nil {
+			if timer == nil {
+				timer = time.NewTimer(bl.interval)
+				defer timer.Stop()
+			} else {
+				timer.Reset(bl.interval)
+			}
+			select {
+			case <-closedChan:
+				return ErrClosedAlready
+			case <-timer.C:
+			}
+		}
+		n -= m
+	}
+	return nil
+}
+
+func (bl *bandwidthLimiter) dispose() {
+	bl.tb.Dispose()
+}
@@ -21,6 +21,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/q191201771/naza/pkg/bitrate"
+	"github.com/q191201771/naza/pkg/mock"
+	"github.com/q191201771/naza/pkg/slicebytepool"
 	"github.com/q191201771/naza/pkg/unique"
 
 	"github.com/q191201771/naza/pkg/nazaatomic"
@@ -32,6 +35,9 @@ var (
 	ErrConnectionPanic = errors.New("naza.connection: using in a wrong way")
 	ErrClosedAlready   = errors.New("naza.connection: connection closed already")
 	ErrWriteChanFull   = errors.New("naza.connection: write channel full")
+
+	ErrClosedGracefully       = errors.New("naza.connection: closed gracefully")
+	ErrCloseGracefullyTimeout = errors.New("naza.connection: close gracefully timeout")
 )
 
 type Connection interface {
@@ -50,10 +56,46 @@ type Connection interface {
 	//
 	Write(b []byte) (n int, err error)
 
+	// WriteWithPriority 和 Write 相同，区别是可以指定消息的优先级，数值越大优先级越高，Write 和 Writev 的优先级为0
+	//
+	// 只有设置了 Option.WriteChanSize ，并且 Option.WriteChanFullBehavior 为 WriteChanFullBehaviorDropLowPriority 时，优先级才有意义：
+	// 队列满时，丢弃队列中优先级最低（相同优先级中最早入队）的消息，如果新消息的优先级不高于队列中所有消息，则丢弃新消息
+	//
+	// 比如流媒体场景，可以给关键帧设置更高的优先级，使得对端消费过慢时优先丢弃非关键帧
+	//
+	WriteWithPriority(b []byte, prio int) (n int, err error)
+
+	// WriteShared 发送带引用计数的内存块，适用于将同一块内存发送给大量连接的场景，避免拷贝
+	//
+	// 如果设置了 Option.WriteChanSize 做异步发送，内部会对`ssb`调用 Ref ，
+	// 并在底层发送完成、被丢弃或者连接关闭后调用 ReleaseIfNeeded ，
+	// 所以上层在调用后，可以立即释放自己持有的引用，内存块将在所有连接都不再使用后归还给 slicebytepool
+	//
+	// 注意，在内存块归还前，上层不应修改其内容
+	//
+	WriteShared(ssb *slicebytepool.SharedSliceByte) (n int, err error)
+
 	// Close 允许调用多次
 	//
 	Close() error
 
+	// CloseGracefully 优雅关闭，按以下步骤执行：
+	//
+	// 1. 不再接收新的写入，之后调用 Write, Writev, Flush 将返回 ErrClosedAlready
+	// 2. 等待 channel 异步发送队列以及 bufio 写缓冲中的数据全部发送完毕
+	// 3. 如果底层连接支持（比如 *net.TCPConn），执行半关闭 CloseWrite ，并读取丢弃对端数据，直到对端也关闭
+	// 4. 关闭连接
+	//
+	// 整个过程的耗时不超过`timeout`
+	//
+	// 注意，调用后上层不应该再并发调用 Read 等读取函数
+	//
+	// @return 成功返回nil，此时 Done 返回 ErrClosedGracefully
+	//         超时返回 ErrCloseGracefullyTimeout ，其他情况返回导致关闭的错误，此时 Done 返回的错误和本函数返回值相同
+	//         如果连接已经关闭或者正在优雅关闭中，返回 ErrClosedAlready
+	//
+	CloseGracefully(timeout time.Duration) error
+
 	LocalAddr() net.Addr
 	RemoteAddr() net.Addr
 	SetDeadline(t time.Time) error
@@ -71,6 +113,8 @@ type Connection interface {
 	//
 	// 注意，如果需要发送的是一块连续的内存块，建议使用 Write 发送
 	//
+	// 注意，如果底层连接不支持writev（比如 *tls.Conn），总大小较小的多个内存块会先合并成一块再发送
+	//
 	Writev(b net.Buffers) (n int, err error)
 
 	ReadAtLeast(buf []byte, min int) (n int, err error)
@@ -92,6 +136,7 @@ type Connection interface {
 	// 注意，向上层严格保证，消息只发送一次
 	//
 	// @return 返回nil则是本端主动调用Close关闭
+	//         返回 ErrClosedGracefully 则是本端调用 CloseGracefully 并顺利完成
 	//
 	Done() <-chan error
 
@@ -106,7 +151,10 @@ type Connection interface {
 	ModReadTimeoutMs(n int)
 	ModWriteTimeoutMs(n int)
 
-	// GetStat 连接上读取和发送的字节总数。
+	// UniqueKey 连接的唯一标识，进程内唯一，比如`NAZACONN1`
+	UniqueKey() string
+
+	// GetStat 连接上读取和发送的字节总数、码率、发送队列情况等统计。
 	// 注意，如果是异步发送，发送字节统计的是调用底层write的值，而非上层调用Connection发送的值
 	// 也即不包含Connection中的发送缓存部分，但是可能包含内核socket发送缓冲区的值。
 	GetStat() Stat
@@ -115,11 +163,41 @@ type Connection interface {
 type Stat struct {
 	ReadBytesSum  uint64
 	WroteBytesSum uint64
+
+	// 读/写的实时码率，单位 kbit/s ，统计窗口为1秒
+	ReadBitrate  float32
+	WroteBitrate float32
+
+	// 异步发送队列中等待发送的消息数和字节数，只有设置了 Option.WriteChanSize 才有意义
+	// 可用于判断对端是否消费过慢
+	PendingWriteMsgNum int
+	PendingWriteBytes  int64
+
+	// 由于异步发送队列满，Write 等返回 ErrWriteChanFull 的次数
+	WriteChanFullCount uint64
+
+	// 异步发送队列满时，各丢弃策略丢弃的消息数，见 WriteChanFullBehavior
+	DropOldestCount      uint64
+	DropNewestCount      uint64
+	DropLowPriorityCount uint64
+
+	// 最后一次读/写到数据的时间，从未读/写过则为零值
+	LastReadTime  time.Time
+	LastWriteTime time.Time
+
+	// 连接创建至今的时长
+	Age time.Duration
 }
 
 type StatAtomic struct {
-	ReadBytesSum  nazaatomic.Uint64
-	WroteBytesSum nazaatomic.Uint64
+	ReadBytesSum         nazaatomic.Uint64
+	WroteBytesSum        nazaatomic.Uint64
+	WriteChanFullCount   nazaatomic.Uint64
+	DropOldestCount      nazaatomic.Uint64
+	DropNewestCount      nazaatomic.Uint64
+	DropLowPriorityCount nazaatomic.Uint64
+	LastReadUnixNano     nazaatomic.Int64
+	LastWriteUnixNano    nazaatomic.Int64
 }
 
 type WriteChanFullBehavior int
@@ -127,6 +205,9 @@ type WriteChanFullBehavior int
 const (
 	WriteChanFullBehaviorReturnError WriteChanFullBehavior = iota + 1
 	WriteChanFullBehaviorBlock
+	WriteChanFullBehaviorDropOldest
+	WriteChanFullBehaviorDropNewest
+	WriteChanFullBehaviorDropLowPriority
 )
 
 type Option struct {
@@ -143,8 +224,35 @@ type Option struct {
 
 	// 使用channel发送数据时，channel满了时Write函数的行为
 	// WriteChanFullBehaviorReturnError 返回错误
-	// WriteChanFullBehaviorBlock 阻塞直到向channel写入成功
+	// WriteChanFullBehaviorBlock 阻塞直到向channel写入成功，或者连接关闭
+	// WriteChanFullBehaviorDropOldest 丢弃channel中最早的消息，新消息入队
+	// WriteChanFullBehaviorDropNewest 丢弃新消息
+	// WriteChanFullBehaviorDropLowPriority 按优先级丢弃，见 WriteWithPriority
+	//
+	// 注意，丢弃类的行为，Write函数依然返回成功，丢弃的消息数可通过 GetStat 获取
 	WriteChanFullBehavior WriteChanFullBehavior
+
+	// 如果不为0，则限制该连接的读/写带宽，单位字节/秒
+	// 注意，GetStat 中的字节统计不受影响，依然是实际读写的值
+	ReadBytesPerSec  int
+	WriteBytesPerSec int
+
+	// 如果不为0，则开启空闲检测，见 IdleType
+	// 与 ReadTimeoutMs 不同，空闲检测不会让单次读写失败，而是由内部协程定时检查，空闲时回调 OnIdle
+	ReadIdleMs  int
+	WriteIdleMs int
+	AllIdleMs   int
+
+	// 空闲时的回调，比如可以在回调中发送心跳。每个空闲周期只回调一次
+	// 注意，回调在内部的检测协程中执行，不应阻塞
+	OnIdle func(c Connection, t IdleType)
+
+	// 空闲时是否关闭连接，如果为true，则回调 OnIdle 后关闭连接，Done 返回 ErrIdleTimeout
+	IsCloseOnIdle bool
+
+	// 读写超时、统计中的时间点、空闲检测等使用的时间源，为nil时使用系统时间
+	// 单元测试时可替换成 mock.NewFakeClock ，比如配合 fake.NewConnPair 模拟超时
+	Clock mock.Clock
 }
 
 // 没有配置的属性，将按如下配置
@@ -155,6 +263,14 @@ var defaultOption = Option{
 	WriteTimeoutMs:        0,
 	WriteChanSize:         0,
 	WriteChanFullBehavior: WriteChanFullBehaviorReturnError,
+	ReadBytesPerSec:       0,
+	WriteBytesPerSec:      0,
+	ReadIdleMs:            0,
+	WriteIdleMs:           0,
+	AllIdleMs:             0,
+	OnIdle:                nil,
+	IsCloseOnIdle:         false,
+	Clock:                 mock.NewStdClock(),
 }
 
 type ModOption func(option *Option)
@@ -163,13 +279,20 @@ func New(conn net.Conn, modOptions ...ModOption) Connection {
 	c := new(connection)
 	c.uniqueKey = uniqueGen.GenUniqueKey()
 	c.doneChan = make(chan error, 1)
+	c.closedChan = make(chan struct{})
 	c.Conn = conn
+	c.rBitrate = bitrate.New()
+	c.wBitrate = bitrate.New()
 
 	c.option = defaultOption
 
 	for _, fn := range modOptions {
 		fn(&c.option)
 	}
+	if c.option.Clock == nil {
+		c.option.Clock = mock.NewStdClock()
+	}
+	c.createTime = c.option.Clock.Now()
 
 	if c.option.ReadBufSize > 0 {
 		c.r = bufio.NewReaderSize(conn, c.option.ReadBufSize)
@@ -183,13 +306,24 @@ func New(conn net.Conn, modOptions ...ModOption) Connection {
 		c.w = conn
 	}
 
+	if c.option.ReadBytesPerSec > 0 {
+		c.rLimiter = newBandwidthLimiter(c.option.ReadBytesPerSec)
+	}
+	if c.option.WriteBytesPerSec > 0 {
+		c.wLimiter = newBandwidthLimiter(c.option.WriteBytesPerSec)
+	}
+
 	if c.option.WriteChanSize > 0 {
-		c.wChan = make(chan wMsg, c.option.WriteChanSize)
+		c.wQueue = newWriteQueue(c.option.WriteChanSize)
 		c.flushDoneChan = make(chan struct{}, 1)
 		c.exitChan = make(chan struct{}, 1)
 		go c.runWriteLoop()
 	}
 
+	if c.isIdleEnabled() {
+		go c.runIdleLoop()
+	}
+
 	nazalog.Debugf("[%s] lifecycle new connection. net.Conn=%p, naza.Connection=%p", c.uniqueKey, conn, c)
 	return c
 }
@@ -204,9 +338,19 @@ const (
 )
 
 type wMsg struct {
-	t  wMsgType
-	b  []byte
-	bs net.Buffers
+	t    wMsgType
+	b    []byte
+	bs   net.Buffers
+	size int // 需要发送的字节数，用于统计
+	prio int
+	ssb  *slicebytepool.SharedSliceByte // 不为nil时，b 为 ssb.Core ，消息处理完后需要释放引用
+}
+
+// releaseMsg 消息发送完成、被丢弃或者连接关闭后调用
+func releaseMsg(msg wMsg) {
+	if msg.ssb != nil {
+		msg.ssb.ReleaseIfNeeded()
+	}
 }
 
 type connection struct {
@@ -215,13 +359,21 @@ type connection struct {
 	w             io.Writer
 	option        Option
 	uniqueKey     string
-	wChan         chan wMsg
+	wQueue        *writeQueue
 	flushDoneChan chan struct{}
 	exitChan      chan struct{}
 	doneChan      chan error
+	closedChan    chan struct{} // 连接关闭时close，用于唤醒内部等待中的协程
 	closedFlag    nazaatomic.Bool
+	closingFlag   nazaatomic.Bool // 正在执行 CloseGracefully
 	closeOnce     sync.Once
+	closeErr      error // 导致关闭的错误，closedChan 关闭后才可以读取
 	stat          StatAtomic
+	createTime    time.Time
+	rBitrate      bitrate.Bitrate
+	wBitrate      bitrate.Bitrate
+	rLimiter      *bandwidthLimiter
+	wLimiter      *bandwidthLimiter
 }
 
 var uniqueGen *unique.SingleGenerator
@@ -235,7 +387,7 @@ func (c *connection) ModWriteChanSize(n int) {
 	}
 
 	c.option.WriteChanSize = n
-	c.wChan = make(chan wMsg, n)
+	c.wQueue = newWriteQueue(n)
 	c.flushDoneChan = make(chan struct{}, 1)
 	c.exitChan = make(chan struct{}, 1)
 	go c.runWriteLoop()
@@ -267,7 +419,7 @@ func (c *connection) ModWriteTimeoutMs(n int) {
 
 func (c *connection) ReadAtLeast(buf []byte, min int) (n int, err error) {
 	if c.option.ReadTimeoutMs > 0 {
-		err = c.SetReadDeadline(time.Now().Add(time.Duration(c.option.ReadTimeoutMs) * time.Millisecond))
+		err = c.SetReadDeadline(c.option.Clock.Now().Add(time.Duration(c.option.ReadTimeoutMs) * time.Millisecond))
 		if err != nil {
 			c.close(err)
 			return 0, err
@@ -277,7 +429,10 @@ func (c *connection) ReadAtLeast(buf []byte, min int) (n int, err error) {
 	if err != nil {
 		c.close(err)
 	}
-	c.stat.ReadBytesSum.Add(uint64(n))
+	c.onRead(n)
+	if err == nil && c.rLimiter != nil {
+		err = c.rLimiter.wait(n, c.closedChan)
+	}
 	return n, err
 }
 
@@ -289,7 +444,7 @@ func (c *connection) ReadLine() (line []byte, isPrefix bool, err error) {
 		panic(ErrConnectionPanic)
 	}
 	if c.option.ReadTimeoutMs > 0 {
-		err = c.SetReadDeadline(time.Now().Add(time.Duration(c.option.ReadTimeoutMs) * time.Millisecond))
+		err = c.SetReadDeadline(c.option.Clock.Now().Add(time.Duration(c.option.ReadTimeoutMs) * time.Millisecond))
 		if err != nil {
 			c.close(err)
 			return nil, false, err
@@ -299,79 +454,94 @@ func (c *connection) ReadLine() (line []byte, isPrefix bool, err error) {
 	if err != nil {
 		c.close(err)
 	}
-	c.stat.ReadBytesSum.Add(uint64(len(line)))
+	c.onRead(len(line))
+	if err == nil && c.rLimiter != nil {
+		err = c.rLimiter.wait(len(line), c.closedChan)
+	}
 	return line, isPrefix, err
 }
 
 func (c *connection) Read(b []byte) (n int, err error) {
 	if c.option.ReadTimeoutMs > 0 {
-		err = c.SetReadDeadline(time.Now().Add(time.Duration(c.option.ReadTimeoutMs) * time.Millisecond))
+		err = c.SetReadDeadline(c.option.Clock.Now().Add(time.Duration(c.option.ReadTimeoutMs) * time.Millisecond))
 		if err != nil {
 			c.close(err)
 			return 0, err
 		}
 	}
+	if c.rLimiter != nil && len(b) > c.rLimiter.chunk {
+		// 限制单次读取的大小，使得读取更平滑
+		b = b[:c.rLimiter.chunk]
+	}
 	n, err = c.r.Read(b)
 	if err != nil {
 		c.close(err)
 	}
-	c.stat.ReadBytesSum.Add(uint64(n))
+	c.onRead(n)
+	if err == nil && c.rLimiter != nil {
+		err = c.rLimiter.wait(n, c.closedChan)
+	}
 	return n, err
 }
 
 func (c *connection) Write(b []byte) (n int, err error) {
-	if c.closedFlag.Load() {
+	return c.WriteWithPriority(b, 0)
+}
+
+func (c *connection) WriteWithPriority(b []byte, prio int) (n int, err error) {
+	if c.closedFlag.Load() || c.closingFlag.Load() {
 		return 0, ErrClosedAlready
 	}
 	if c.option.WriteChanSize > 0 {
-		switch c.option.WriteChanFullBehavior {
-		case WriteChanFullBehaviorBlock:
-			c.wChan <- wMsg{t: wMsgTypeWrite, b: b}
-			return len(b), nil
-		case WriteChanFullBehaviorReturnError:
-			select {
-			case c.wChan <- wMsg{t: wMsgTypeWrite, b: b}:
-				return len(b), nil
-			default:
-				return 0, ErrWriteChanFull
-			}
+		if err = c.pushToWriteQueue(wMsg{t: wMsgTypeWrite, b: b, size: len(b), prio: prio}); err != nil {
+			return 0, err
 		}
+		return len(b), nil
 	}
 	return c.write(b)
 }
 
+func (c *connection) WriteShared(ssb *slicebytepool.SharedSliceByte) (n int, err error) {
+	if c.closedFlag.Load() || c.closingFlag.Load() {
+		return 0, ErrClosedAlready
+	}
+	if c.option.WriteChanSize > 0 {
+		if err = c.pushToWriteQueue(wMsg{t: wMsgTypeWrite, b: ssb.Core, size: len(ssb.Core), ssb: ssb.Ref()}); err != nil {
+			return 0, err
+		}
+		return len(ssb.Core), nil
+	}
+	return c.write(ssb.Core)
+}
+
 func (c *connection) Writev(b net.Buffers) (n int, err error) {
-	if c.closedFlag.Load() {
+	if c.closedFlag.Load() || c.closingFlag.Load() {
 		return 0, ErrClosedAlready
 	}
 	if c.option.WriteChanSize > 0 {
 		for _, v := range b {
 			n += len(v)
 		}
-		switch c.option.WriteChanFullBehavior {
-		case WriteChanFullBehaviorBlock:
-			c.wChan <- wMsg{t: wMsgTypeWritev, bs: b}
-			return n, nil
-		case WriteChanFullBehaviorReturnError:
-			select {
-			case c.wChan <- wMsg{t: wMsgTypeWritev, bs: b}:
-				return n, nil
-			default:
-				return 0, ErrWriteChanFull
-			}
+		if err = c.pushToWriteQueue(wMsg{t: wMsgTypeWritev, bs: b, size: n}); err != nil {
+			return 0, err
 		}
+		return n, nil
 	}
 	return c.writev(b)
 }
 
 func (c *connection) Flush() error {
-	if c.closedFlag.Load() {
+	if c.closedFlag.Load() || c.closingFlag.Load() {
 		return ErrClosedAlready
 	}
 	if c.option.WriteChanSize > 0 {
-		c.wChan <- wMsg{t: wMsgTypeFlush}
-		<-c.flushDoneChan
-		return nil
+		c.wQueue.tryPush(wMsg{t: wMsgTypeFlush})
+		select {
+		case <-c.flushDoneChan:
+			return nil
+		case <-c.closedChan:
+			return ErrClosedAlready
+		}
 	}
 
 	return c.flush()
@@ -383,6 +553,23 @@ func (c *connection) Close() error {
 	return nil
 }
 
+func (c *connection) CloseGracefully(timeout time.Duration) error {
+	if c.closedFlag.Load() || !c.closingFlag.CompareAndSwap(false, true) {
+		return ErrClosedAlready
+	}
+	nazalog.Debugf("[%s] CloseGracefully. timeout=%v", c.uniqueKey, timeout)
+
+	err := c.closeGracefully(c.option.Clock.Now().Add(timeout))
+	c.close(err)
+
+	// 过程中可能因为其他错误已经关闭了，以实际关闭的原因为准
+	<-c.closedChan
+	if c.closeErr == ErrClosedGracefully {
+		return nil
+	}
+	return c.closeErr
+}
+
 func (c *connection) Done() <-chan error {
 	return c.doneChan
 }
@@ -419,15 +606,64 @@ func (c *connection) SetWriteDeadline(t time.Time) error {
 	return err
 }
 
+func (c *connection) UniqueKey() string {
+	return c.uniqueKey
+}
+
 func (c *connection) GetStat() (s Stat) {
+	now := c.option.Clock.Now()
+	nowUnixMs := now.UnixNano() / 1e6
+
 	s.ReadBytesSum = c.stat.ReadBytesSum.Load()
 	s.WroteBytesSum = c.stat.WroteBytesSum.Load()
+	s.ReadBitrate = c.rBitrate.Rate(nowUnixMs)
+	s.WroteBitrate = c.wBitrate.Rate(nowUnixMs)
+	if c.option.WriteChanSize > 0 {
+		s.PendingWriteMsgNum, s.PendingWriteBytes = c.wQueue.status()
+	}
+	s.WriteChanFullCount = c.stat.WriteChanFullCount.Load()
+	s.DropOldestCount = c.stat.DropOldestCount.Load()
+	s.DropNewestCount = c.stat.DropNewestCount.Load()
+	s.DropLowPriorityCount = c.stat.DropLowPriorityCount.Load()
+	if t := c.stat.LastReadUnixNano.Load(); t != 0 {
+		s.LastReadTime = time.Unix(0, t)
+	}
+	if t := c.stat.LastWriteUnixNano.Load(); t != 0 {
+		s.LastWriteTime = time.Unix(0, t)
+	}
+	s.Age = now.Sub(c.createTime)
 	return
 }
 
+func (c *connection) onRead(n int) {
+	c.stat.ReadBytesSum.Add(uint64(n))
+	if n == 0 {
+		return
+	}
+	now := c.option.Clock.Now().UnixNano()
+	c.stat.LastReadUnixNano.Store(now)
+	c.rBitrate.Add(n, now/1e6)
+}
+
+func (c *connection) onWrote(n int) {
+	c.stat.WroteBytesSum.Add(uint64(n))
+	if n == 0 {
+		return
+	}
+	now := c.option.Clock.Now().UnixNano()
+	c.stat.LastWriteUnixNano.Store(now)
+	c.wBitrate.Add(n, now/1e6)
+}
+
 func (c *connection) write(b []byte) (n int, err error) {
+	// 先等待带宽额度再设置超时，等待的时间不计入写超时
+	if c.wLimiter != nil {
+		if err = c.wLimiter.wait(len(b), c.closedChan); err != nil {
+			return 0, err
+		}
+	}
 	if c.option.WriteTimeoutMs > 0 {
-		err = c.SetWriteDeadline(time.Now().Add(time.Duration(c.option.WriteTimeoutMs) * time.Millisecond))
+		err = c.SetWriteDeadline(c.option.Clock.Now().Add(time.Duration(c.option.WriteTimeoutMs) * time.Millisecond))
 		if err != nil {
 			c.close(err)
 			return 0, err
@@ -437,60 +673,198 @@ func (c *connection) write(b []byte) (n int, err error) {
 	if err != nil {
 		c.close(err)
 	}
-	c.stat.WroteBytesSum.Add(uint64(n))
+	c.onWrote(n)
 	return n, err
 }
 
 func (c *connection) writev(b net.Buffers) (n int, err error) {
+	// 先等待带宽额度再设置超时，等待的时间不计入写超时
+	if c.wLimiter != nil {
+		var total int
+		for _, v := range b {
+			total += len(v)
+		}
+		if err = c.wLimiter.wait(total, c.closedChan); err != nil {
+			return 0, err
+		}
+	}
 	if c.option.WriteTimeoutMs > 0 {
-		err = c.SetWriteDeadline(time.Now().Add(time.Duration(c.option.WriteTimeoutMs) * time.Millisecond))
+		err = c.SetWriteDeadline(c.option.Clock.Now().Add(time.Duration(c.option.WriteTimeoutMs) * time.Millisecond))
 		if err != nil {
 			c.close(err)
 			return 0, err
 		}
 	}
 	var n64 int64
-	n64, err = b.WriteTo(c.w)
+	n64, err = writeBuffers(c.w, b)
 	if err != nil {
 		c.close(err)
 	}
 	n = int(n64)
-	c.stat.WroteBytesSum.Add(uint64(n))
+	c.onWrote(n)
 	return n, err
 }
 
+func (c *connection) pushToWriteQueue(msg wMsg) error {
+	switch c.option.WriteChanFullBehavior {
+	case WriteChanFullBehaviorBlock:
+		for !c.wQueue.tryPush(msg) {
+			select {
+			case <-c.wQueue.spaceChan:
+			case <-c.closedChan:
+				releaseMsg(msg)
+				return ErrClosedAlready
+			}
+		}
+	case WriteChanFullBehaviorDropOldest:
+		if dropped, ok := c.wQueue.pushWithDrop(msg, pickOldest); ok {
+			releaseMsg(dropped)
+			c.stat.DropOldestCount.Increment()
+		}
+	case WriteChanFullBehaviorDropNewest:
+		if !c.wQueue.tryPush(msg) {
+			releaseMsg(msg)
+			c.stat.DropNewestCount.Increment()
+		}
+	case WriteChanFullBehaviorDropLowPriority:
+		if dropped, ok := c.wQueue.pushWithDrop(msg, pickLowestPriority); ok {
+			releaseMsg(dropped)
+			c.stat.DropLowPriorityCount.Increment()
+		}
+	default:
+		if !c.wQueue.tryPush(msg) {
+			releaseMsg(msg)
+			c.stat.WriteChanFullCount.Increment()
+			return ErrWriteChanFull
+		}
+	}
+	return nil
+}
+
 func (c *connection) runWriteLoop() {
+	// 退出后队列中剩余的消息不会再发送了，释放它们
+	defer c.wQueue.dispose()
+
 	for {
-		select {
-		case <-c.exitChan:
-			//nazalog.Debugf("[%s] recv exitChan and exit write loop", c.uniqueKey)
-			return
-		case msg := <-c.wChan:
-			switch msg.t {
-			case wMsgTypeWrite:
-				if _, err := c.write(msg.b); err != nil {
-					return
-				}
-			case wMsgTypeWritev:
-				if _, err := c.writev(msg.bs); err != nil {
-					return
-				}
-			case wMsgTypeFlush:
-				if err := c.flush(); err != nil {
-					c.flushDoneChan <- struct{}{}
-					return
-				}
+		msg, ok := c.wQueue.pop()
+		if !ok {
+			select {
+			case <-c.exitChan:
+				//nazalog.Debugf("[%s] recv exitChan and exit write loop", c.uniqueKey)
+				return
+			case <-c.wQueue.readyChan:
+				continue
+			}
+		}
+
+		switch msg.t {
+		case wMsgTypeWrite:
+			_, err := c.write(msg.b)
+			releaseMsg(msg)
+			if err != nil {
+				return
+			}
+		case wMsgTypeWritev:
+			if _, err := c.writev(msg.bs); err != nil {
+				return
+			}
+		case wMsgTypeFlush:
+			if err := c.flush(); err != nil {
 				c.flushDoneChan <- struct{}{}
+				return
 			}
+			c.flushDoneChan <- struct{}{}
+		}
+	}
+}
+
+// pickOldest 选择队列中最早的非flush消息
+func pickOldest(msgs []wMsg, msg wMsg) int {
+	for i := range msgs {
+		if msgs[i].t != wMsgTypeFlush {
+			return i
+		}
+	}
+	return -1
+}
+
+// pickLowestPriority 选择队列中优先级最低的非flush消息，相同优先级选择最早入队的
+// 如果新消息的优先级不高于它，则选择新消息
+func pickLowestPriority(msgs []wMsg, msg wMsg) int {
+	ret := -1
+	for i := range msgs {
+		if msgs[i].t == wMsgTypeFlush {
+			continue
+		}
+		if ret == -1 || msgs[i].prio < msgs[ret].prio {
+			ret = i
+		}
+	}
+	if ret != -1 && msgs[ret].prio >= msg.prio {
+		return -1
+	}
+	return ret
+}
+
+func (c *connection) closeGracefully(deadline time.Time) error {
+	timer := c.option.Clock.NewTimer(deadline.Sub(c.option.Clock.Now()))
+	defer timer.Stop()
+
+	// 如果没有设置写超时，则用整体的截止时间兜底，避免阻塞在底层写上
+	if c.option.WriteTimeoutMs == 0 {
+		if err := c.Conn.SetWriteDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	if c.option.WriteChanSize > 0 {
+		c.wQueue.tryPush(wMsg{t: wMsgTypeFlush})
+		select {
+		case <-c.flushDoneChan:
+		case <-timer.C:
+			return ErrCloseGracefullyTimeout
+		case <-c.closedChan:
+			return c.closeErr
+		}
+	} else {
+		if err := c.flush(); err != nil {
+			return err
 		}
 	}
+
+	cw, ok := c.Conn.(interface{ CloseWrite() error })
+	if !ok {
+		return ErrClosedGracefully
+	}
+	if err := cw.CloseWrite(); err != nil {
+		return err
+	}
+
+	if err := c.Conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.r.Read(buf)
+		c.onRead(n)
+		if err == nil {
+			continue
+		}
+		if err == io.EOF {
+			return ErrClosedGracefully
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return ErrCloseGracefullyTimeout
+		}
+		return err
+	}
 }
 
 func (c *connection) flush() error {
 	w, ok := c.w.(*bufio.Writer)
 	if ok {
 		if c.option.WriteTimeoutMs > 0 {
-			err := c.SetWriteDeadline(time.Now().Add(time.Duration(c.option.WriteTimeoutMs) * time.Millisecond))
+			err := c.SetWriteDeadline(c.option.Clock.Now().Add(time.Duration(c.option.WriteTimeoutMs) * time.Millisecond))
 			if err != nil {
 				c.close(err)
 				return err
@@ -508,6 +882,14 @@ func (c *connection) close(err error) {
 	c.closeOnce.Do(func() {
 		nazalog.Debugf("[%s] close once. err=%+v", c.uniqueKey, err)
 		c.closedFlag.Store(true)
+		c.closeErr = err
+		close(c.closedChan)
+		if c.rLimiter != nil {
+			c.rLimiter.dispose()
+		}
+		if c.wLimiter != nil {
+			c.wLimiter.dispose()
+		}
 		if c.option.WriteChanSize > 0 {
 			c.exitChan <- struct{}{}
 		}
@@ -516,7 +898,7 @@ func (c *connection) close(err error) {
 		_ = c.Conn.Close()
 		c.doneChan <- err
 
-		// 注意，如果使用了wChan，并不关闭它，避免竞态条件下connection继续使用它造成问题。让它随connection对象释放。
+		// 注意，如果使用了wQueue，并不关闭它，避免竞态条件下connection继续使用它造成问题。让它随connection对象释放。
 	})
 }
 
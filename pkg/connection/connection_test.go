@@ -9,6 +9,8 @@
 package connection_test
 
 import (
+	"io"
+	"io/ioutil"
 	"math/rand"
 	"net"
 	"sync"
@@ -17,9 +19,13 @@ import (
 	"time"
 
 	"github.com/q191201771/naza/pkg/connection"
+	"github.com/q191201771/naza/pkg/fake"
+	"github.com/q191201771/naza/pkg/mock"
 
 	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/nazaatomic"
 	"github.com/q191201771/naza/pkg/nazalog"
+	"github.com/q191201771/naza/pkg/slicebytepool"
 )
 
 func TestWriteTimeout(t *testing.T) {
@@ -178,3 +184,428 @@ func testWithConnPair(t *testing.T, cb func(srvConn, cliConn net.Conn)) {
 
 	cb(srvConn, cliConn)
 }
+
+func TestConnection_CloseGracefully(t *testing.T) {
+	goldenB := []byte{'a', 'b', 'c', 'd', 'e'}
+
+	// 对端读完数据后关闭，优雅关闭成功
+	for _, writeChanSize := range []int{0, 128} {
+		testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+			c := connection.New(cliConn, func(option *connection.Option) {
+				option.WriteChanSize = writeChanSize
+				option.WriteBufSize = 1024
+			})
+			for i := 0; i < 16; i++ {
+				_, err := c.Write(goldenB)
+				assert.Equal(t, nil, err)
+			}
+
+			go func() {
+				b, err := ioutil.ReadAll(srvConn)
+				assert.Equal(t, nil, err)
+				assert.Equal(t, 16*len(goldenB), len(b))
+				srvConn.Close()
+			}()
+
+			err := c.CloseGracefully(5 * time.Second)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, connection.ErrClosedGracefully, <-c.Done())
+
+			_, err = c.Write(goldenB)
+			assert.Equal(t, connection.ErrClosedAlready, err)
+			err = c.CloseGracefully(time.Second)
+			assert.Equal(t, connection.ErrClosedAlready, err)
+		})
+	}
+
+	// 对端一直不关闭，超时
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		c := connection.New(cliConn)
+		_, err := c.Write(goldenB)
+		assert.Equal(t, nil, err)
+
+		err = c.CloseGracefully(100 * time.Millisecond)
+		assert.Equal(t, connection.ErrCloseGracefullyTimeout, err)
+		assert.Equal(t, connection.ErrCloseGracefullyTimeout, <-c.Done())
+		srvConn.Close()
+	})
+}
+
+func TestConnection_BytesPerSec(t *testing.T) {
+	// 令牌桶初始为空，10000字节/秒的速率下，3000字节至少需要300毫秒
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		c := connection.New(cliConn, func(option *connection.Option) {
+			option.WriteBytesPerSec = 10000
+		})
+		go func() {
+			_, _ = ioutil.ReadAll(srvConn)
+		}()
+		b := make([]byte, 1000)
+		begin := time.Now()
+		for i := 0; i < 3; i++ {
+			n, err := c.Write(b)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, 1000, n)
+		}
+		assert.Equal(t, true, time.Since(begin) > 250*time.Millisecond)
+		assert.Equal(t, uint64(3000), c.GetStat().WroteBytesSum)
+		c.Close()
+		srvConn.Close()
+	})
+
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		c := connection.New(cliConn, func(option *connection.Option) {
+			option.ReadBytesPerSec = 10000
+		})
+		go func() {
+			_, _ = srvConn.Write(make([]byte, 3000))
+		}()
+		b := make([]byte, 3000)
+		begin := time.Now()
+		_, err := c.ReadAtLeast(b, 3000)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, time.Since(begin) > 250*time.Millisecond)
+		assert.Equal(t, uint64(3000), c.GetStat().ReadBytesSum)
+		c.Close()
+		srvConn.Close()
+	})
+}
+
+func TestConnection_GetStat(t *testing.T) {
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		c := connection.New(cliConn)
+		s := c.GetStat()
+		assert.Equal(t, true, s.LastReadTime.IsZero())
+		assert.Equal(t, true, s.LastWriteTime.IsZero())
+
+		_, err := c.Write(make([]byte, 1000))
+		assert.Equal(t, nil, err)
+		_, err = srvConn.Write(make([]byte, 500))
+		assert.Equal(t, nil, err)
+		_, err = c.ReadAtLeast(make([]byte, 500), 500)
+		assert.Equal(t, nil, err)
+
+		s = c.GetStat()
+		assert.Equal(t, uint64(500), s.ReadBytesSum)
+		assert.Equal(t, uint64(1000), s.WroteBytesSum)
+		assert.Equal(t, float32(4), s.ReadBitrate)
+		assert.Equal(t, float32(8), s.WroteBitrate)
+		assert.Equal(t, false, s.LastReadTime.IsZero())
+		assert.Equal(t, false, s.LastWriteTime.IsZero())
+		assert.Equal(t, true, s.Age > 0)
+
+		c.Close()
+		srvConn.Close()
+	})
+
+	// 对端不读，直到发送队列满
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		c := connection.New(cliConn, func(option *connection.Option) {
+			option.WriteChanSize = 4
+		})
+		b := make([]byte, 1024*1024)
+		for {
+			if _, err := c.Write(b); err == connection.ErrWriteChanFull {
+				break
+			}
+		}
+		s := c.GetStat()
+		assert.Equal(t, uint64(1), s.WriteChanFullCount)
+		assert.Equal(t, true, s.PendingWriteMsgNum > 0)
+		assert.Equal(t, int64(s.PendingWriteMsgNum*len(b)), s.PendingWriteBytes)
+
+		c.Close()
+		srvConn.Close()
+	})
+}
+
+func TestConnection_WriteChanFullBehaviorDrop(t *testing.T) {
+	// 先发送一块很大的数据，对端不读，使得后台发送协程阻塞在这块数据上，再测试后续消息的丢弃
+	big := make([]byte, 32*1024*1024)
+
+	type msg struct {
+		size int
+		prio int
+	}
+	vector := []struct {
+		behavior connection.WriteChanFullBehavior
+		msgs     []msg
+		expected int // 对端最终收到的除了大块数据之外的字节数
+		dropped  func(s connection.Stat) uint64
+	}{
+		{
+			behavior: connection.WriteChanFullBehaviorDropOldest,
+			msgs:     []msg{{1, 0}, {2, 0}, {3, 0}, {4, 0}},
+			expected: 3 + 4,
+			dropped:  func(s connection.Stat) uint64 { return s.DropOldestCount },
+		},
+		{
+			behavior: connection.WriteChanFullBehaviorDropNewest,
+			msgs:     []msg{{1, 0}, {2, 0}, {3, 0}, {4, 0}},
+			expected: 1 + 2,
+			dropped:  func(s connection.Stat) uint64 { return s.DropNewestCount },
+		},
+		{
+			behavior: connection.WriteChanFullBehaviorDropLowPriority,
+			msgs:     []msg{{1, 1}, {2, 0}, {3, 2}, {4, 0}},
+			expected: 1 + 3,
+			dropped:  func(s connection.Stat) uint64 { return s.DropLowPriorityCount },
+		},
+	}
+
+	for i := range vector {
+		v := vector[i]
+		testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+			c := connection.New(cliConn, func(option *connection.Option) {
+				option.WriteChanSize = 2
+				option.WriteChanFullBehavior = v.behavior
+			})
+			_, err := c.Write(big)
+			assert.Equal(t, nil, err)
+			for c.GetStat().PendingWriteMsgNum != 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			for _, m := range v.msgs {
+				n, err := c.WriteWithPriority(make([]byte, m.size), m.prio)
+				assert.Equal(t, nil, err)
+				assert.Equal(t, m.size, n)
+			}
+			s := c.GetStat()
+			assert.Equal(t, uint64(2), v.dropped(s))
+			assert.Equal(t, 2, s.PendingWriteMsgNum)
+
+			ch := make(chan int, 1)
+			go func() {
+				b, _ := ioutil.ReadAll(srvConn)
+				ch <- len(b)
+			}()
+			err = c.Flush()
+			assert.Equal(t, nil, err)
+			c.Close()
+			assert.Equal(t, len(big)+v.expected, <-ch)
+			srvConn.Close()
+		})
+	}
+}
+
+func TestConnection_Idle(t *testing.T) {
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		var mu sync.Mutex
+		var idleTypes []connection.IdleType
+		c := connection.New(cliConn, func(option *connection.Option) {
+			option.ReadIdleMs = 100
+			option.WriteIdleMs = 300
+			option.OnIdle = func(c connection.Connection, t connection.IdleType) {
+				mu.Lock()
+				idleTypes = append(idleTypes, t)
+				mu.Unlock()
+				if t == connection.IdleTypeWrite {
+					_, _ = c.Write([]byte("ping"))
+				}
+			}
+		})
+		go func() {
+			for {
+				// 持续发送数据，使得不会触发读空闲
+				if _, err := srvConn.Write([]byte{'a'}); err != nil {
+					return
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		}()
+		go func() {
+			b := make([]byte, 128)
+			for {
+				if _, err := c.Read(b); err != nil {
+					return
+				}
+			}
+		}()
+		time.Sleep(700 * time.Millisecond)
+		mu.Lock()
+		assert.Equal(t, []connection.IdleType{connection.IdleTypeWrite, connection.IdleTypeWrite}, idleTypes)
+		mu.Unlock()
+		c.Close()
+		srvConn.Close()
+	})
+
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		c := connection.New(cliConn, func(option *connection.Option) {
+			option.AllIdleMs = 100
+			option.IsCloseOnIdle = true
+		})
+		assert.Equal(t, connection.ErrIdleTimeout, <-c.Done())
+		_, err := c.Write([]byte{'a'})
+		assert.Equal(t, connection.ErrClosedAlready, err)
+		srvConn.Close()
+	})
+}
+
+type countPool struct {
+	putCount nazaatomic.Uint32
+}
+
+func (p *countPool) Get(size int) []byte {
+	return make([]byte, size)
+}
+
+func (p *countPool) Put(buf []byte) {
+	p.putCount.Increment()
+}
+
+func (p *countPool) RetrieveStatus() slicebytepool.Status {
+	return slicebytepool.Status{}
+}
+
+func TestConnection_WriteShared(t *testing.T) {
+	// 发送完成后归还
+	pool := &countPool{}
+	ssb := slicebytepool.WrapSharedSliceByte([]byte("hello"), slicebytepool.WithPool(pool))
+	var cs []connection.Connection
+	for i := 0; i < 2; i++ {
+		testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+			c := connection.New(cliConn, func(option *connection.Option) {
+				option.WriteChanSize = 16
+			})
+			n, err := c.WriteShared(ssb)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, 5, n)
+			b := make([]byte, 5)
+			_, err = io.ReadFull(srvConn, b)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, []byte("hello"), b)
+			cs = append(cs, c)
+			srvConn.Close()
+		})
+	}
+	for _, c := range cs {
+		assert.Equal(t, nil, c.Flush())
+	}
+	assert.Equal(t, uint32(0), pool.putCount.Load())
+	ssb.ReleaseIfNeeded()
+	assert.Equal(t, uint32(1), pool.putCount.Load())
+	for _, c := range cs {
+		c.Close()
+	}
+
+	// 连接关闭时，队列中还没发送的也归还
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		pool := &countPool{}
+		c := connection.New(cliConn, func(option *connection.Option) {
+			option.WriteChanSize = 16
+		})
+		_, err := c.Write(make([]byte, 32*1024*1024))
+		assert.Equal(t, nil, err)
+		for c.GetStat().PendingWriteMsgNum != 0 {
+			time.Sleep(time.Millisecond)
+		}
+		ssb := slicebytepool.WrapSharedSliceByte([]byte("hello"), slicebytepool.WithPool(pool))
+		_, err = c.WriteShared(ssb)
+		assert.Equal(t, nil, err)
+		ssb.ReleaseIfNeeded()
+		assert.Equal(t, uint32(0), pool.putCount.Load())
+
+		c.Close()
+		for pool.putCount.Load() != 1 {
+			time.Sleep(time.Millisecond)
+		}
+		srvConn.Close()
+	})
+}
+
+func TestConnection_Bitrate(t *testing.T) {
+	clock := mock.NewFakeClock()
+	clock.Set(time.Unix(1600000000, 0))
+	c1, c2 := fake.NewConnPair()
+	c := connection.New(c1, func(option *connection.Option) {
+		option.Clock = clock
+	})
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, c2)
+	}()
+
+	// 统计窗口为1秒
+	for i := 0; i < 10; i++ {
+		if i != 0 {
+			clock.Add(100 * time.Millisecond)
+		}
+		_, err := c.Write(make([]byte, 100))
+		assert.Equal(t, nil, err)
+	}
+	assert.Equal(t, float32(8), c.GetStat().WroteBitrate)
+	clock.Add(550 * time.Millisecond)
+	assert.Equal(t, float32(4), c.GetStat().WroteBitrate)
+	clock.Add(time.Second)
+	assert.Equal(t, float32(0), c.GetStat().WroteBitrate)
+	assert.Equal(t, uint64(1000), c.GetStat().WroteBytesSum)
+
+	c.Close()
+	c2.Close()
+}
+
+func TestConnection_FakeConn(t *testing.T) {
+	clock := mock.NewFakeClock()
+	clock.Set(time.Unix(1600000000, 0))
+
+	// 读超时
+	c1, c2 := fake.NewConnPair(func(option *fake.ConnOption) {
+		option.Clock = clock
+		option.MaxReadSize = 1
+	})
+	c := connection.New(c1, func(option *connection.Option) {
+		option.ReadTimeoutMs = 1000
+		option.Clock = clock
+	})
+	_, err := c2.Write([]byte("hello"))
+	assert.Equal(t, nil, err)
+	b := make([]byte, 5)
+	n, err := c.ReadAtLeast(b, 5)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, clock.Now(), c.GetStat().LastReadTime)
+
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := c.Read(b)
+		errChan <- err
+	}()
+	assert.Equal(t, fake.ErrFakeConnTimeout, advanceUntilErr(clock, errChan))
+	assert.Equal(t, fake.ErrFakeConnTimeout, <-c.Done())
+
+	// 写超时
+	c1, c2 = fake.NewConnPair(func(option *fake.ConnOption) {
+		option.Clock = clock
+	})
+	c = connection.New(c1, func(option *connection.Option) {
+		option.WriteTimeoutMs = 1000
+		option.Clock = clock
+	})
+	c1.SetWriteBlocked(true)
+	go func() {
+		_, err := c.Write(b)
+		errChan <- err
+	}()
+	assert.Equal(t, fake.ErrFakeConnTimeout, advanceUntilErr(clock, errChan))
+	assert.Equal(t, fake.ErrFakeConnTimeout, <-c.Done())
+
+	// 对端重置
+	c1, c2 = fake.NewConnPair()
+	c = connection.New(c1)
+	c2.Reset()
+	_, err = c.Write(b)
+	assert.Equal(t, fake.ErrFakeConnReset, err)
+	assert.Equal(t, fake.ErrFakeConnReset, <-c.Done())
+}
+
+// advanceUntilErr 不断推进时间直到`errChan`返回，因为无法确定另一个协程是否已经开始阻塞读写
+func advanceUntilErr(clock mock.Clock, errChan chan error) error {
+	for {
+		clock.Add(100 * time.Millisecond)
+		select {
+		case err := <-errChan:
+			return err
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
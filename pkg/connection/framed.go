@@ -0,0 +1,251 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+
+	"github.com/q191201771/naza/pkg/bele"
+)
+
+var ErrMessageTooLarge = errors.New("naza.connection: message too large")
+
+// FramedConnection 在 Connection 之上提供按消息（长度头+消息体）收发的能力
+//
+// 写消息时，消息头和消息体通过 Writev 作为一个整体发送，
+// 所以如果设置了 Option.WriteChanSize 做异步发送，一条消息在发送队列中也只占一个位置
+type FramedConnection interface {
+	Connection
+
+	// ReadMessage 读取一条完整的消息，返回的内存块由内部新申请，上层可以持有
+	//
+	// 注意，如果消息长度超过了 FramedOption.MaxMessageSize ，将返回 ErrMessageTooLarge ，
+	// 由于此时流已经无法再按消息对齐，内部会同时关闭连接
+	//
+	ReadMessage() ([]byte, error)
+
+	// WriteMessage 在 `b` 前面加上长度头后发送
+	//
+	// 注意，如果设置了 Option.WriteChanSize 做异步发送，在消息发送完成前上层不应该修改 `b` 的内容
+	//
+	// 注意，如果消息长度超过了 FramedOption.MaxMessageSize 或者长度头能表示的最大值，返回 ErrMessageTooLarge ，不关闭连接
+	//
+	WriteMessage(b []byte) error
+}
+
+type FrameHeaderType int
+
+const (
+	FrameHeaderTypeUint8 FrameHeaderType = iota + 1
+	FrameHeaderTypeUint16
+	FrameHeaderTypeUint24
+	FrameHeaderTypeUint32
+	FrameHeaderTypeVarint // 使用 encoding/binary 中的 Uvarint 编码
+)
+
+type FramedOption struct {
+	// 长度头的格式
+	HeaderType FrameHeaderType
+
+	// 长度头是否使用小端，默认为大端
+	// 注意，FrameHeaderTypeUint8 和 FrameHeaderTypeVarint 时，该字段无效
+	IsLittleEndian bool
+
+	// 消息体的最大长度（不包含长度头），默认为16MB
+	// 如果为0，则只受长度头能表示的最大值的限制，注意，此时对端可以让接收端申请很大的内存
+	// 无论如何不超过 math.MaxInt32 ，避免32位平台下转换成 int 时溢出
+	MaxMessageSize int
+}
+
+const defaultMaxMessageSize = 16 * 1024 * 1024
+
+var defaultFramedOption = FramedOption{
+	HeaderType:     FrameHeaderTypeUint32,
+	IsLittleEndian: false,
+	MaxMessageSize: defaultMaxMessageSize,
+}
+
+type ModFramedOption func(option *FramedOption)
+
+// NewFramedConnection
+//
+// 注意，如果长度头使用 FrameHeaderTypeVarint ，由于需要逐字节读取长度头，建议 `conn` 设置 Option.ReadBufSize
+func NewFramedConnection(conn Connection, modOptions ...ModFramedOption) FramedConnection {
+	fc := &framedConnection{
+		Connection: conn,
+		option:     defaultFramedOption,
+	}
+	for _, fn := range modOptions {
+		fn(&fc.option)
+	}
+	return fc
+}
+
+type framedConnection struct {
+	Connection
+	option FramedOption
+
+	// 只在 ReadMessage 中使用，写时由于可能是异步发送，所以每次都重新申请
+	rHeader [binary.MaxVarintLen64]byte
+}
+
+func (fc *framedConnection) ReadMessage() ([]byte, error) {
+	n, err := fc.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	if fc.isTooLarge(n) {
+		fc.closeWithError(ErrMessageTooLarge)
+		return nil, ErrMessageTooLarge
+	}
+
+	b := make([]byte, int(n))
+	if n == 0 {
+		return b, nil
+	}
+	if _, err = fc.ReadAtLeast(b, len(b)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (fc *framedConnection) WriteMessage(b []byte) error {
+	if fc.isTooLarge(uint64(len(b))) {
+		return ErrMessageTooLarge
+	}
+	header := fc.packHeader(len(b))
+	_, err := fc.Writev(net.Buffers{header, b})
+	return err
+}
+
+func (fc *framedConnection) readHeader() (uint64, error) {
+	if fc.option.HeaderType == FrameHeaderTypeVarint {
+		return fc.readVarintHeader()
+	}
+
+	h := fc.rHeader[:fc.headerSize()]
+	if _, err := fc.ReadAtLeast(h, len(h)); err != nil {
+		return 0, err
+	}
+	switch fc.option.HeaderType {
+	case FrameHeaderTypeUint8:
+		return uint64(h[0]), nil
+	case FrameHeaderTypeUint16:
+		if fc.option.IsLittleEndian {
+			return uint64(bele.LeUint16(h)), nil
+		}
+		return uint64(bele.BeUint16(h)), nil
+	case FrameHeaderTypeUint24:
+		if fc.option.IsLittleEndian {
+			return uint64(bele.LeUint24(h)), nil
+		}
+		return uint64(bele.BeUint24(h)), nil
+	case FrameHeaderTypeUint32:
+		if fc.option.IsLittleEndian {
+			return uint64(bele.LeUint32(h)), nil
+		}
+		return uint64(bele.BeUint32(h)), nil
+	}
+	panic(ErrConnectionPanic)
+}
+
+func (fc *framedConnection) readVarintHeader() (uint64, error) {
+	// 逐字节读取，直到最高位为0
+	for i := 0; i < len(fc.rHeader); i++ {
+		if _, err := fc.ReadAtLeast(fc.rHeader[i:i+1], 1); err != nil {
+			return 0, err
+		}
+		if fc.rHeader[i] < 0x80 {
+			// 超过64位的varint也当作消息过大处理
+			n, k := binary.Uvarint(fc.rHeader[:i+1])
+			if k <= 0 {
+				break
+			}
+			return n, nil
+		}
+	}
+	fc.closeWithError(ErrMessageTooLarge)
+	return 0, ErrMessageTooLarge
+}
+
+func (fc *framedConnection) packHeader(n int) []byte {
+	if fc.option.HeaderType == FrameHeaderTypeVarint {
+		h := make([]byte, binary.MaxVarintLen64)
+		return h[:binary.PutUvarint(h, uint64(n))]
+	}
+
+	h := make([]byte, fc.headerSize())
+	switch fc.option.HeaderType {
+	case FrameHeaderTypeUint8:
+		h[0] = uint8(n)
+	case FrameHeaderTypeUint16:
+		if fc.option.IsLittleEndian {
+			bele.LePutUint16(h, uint16(n))
+		} else {
+			bele.BePutUint16(h, uint16(n))
+		}
+	case FrameHeaderTypeUint24:
+		if fc.option.IsLittleEndian {
+			bele.LePutUint24(h, uint32(n))
+		} else {
+			bele.BePutUint24(h, uint32(n))
+		}
+	case FrameHeaderTypeUint32:
+		if fc.option.IsLittleEndian {
+			bele.LePutUint32(h, uint32(n))
+		} else {
+			bele.BePutUint32(h, uint32(n))
+		}
+	}
+	return h
+}
+
+func (fc *framedConnection) headerSize() int {
+	switch fc.option.HeaderType {
+	case FrameHeaderTypeUint8:
+		return 1
+	case FrameHeaderTypeUint16:
+		return 2
+	case FrameHeaderTypeUint24:
+		return 3
+	case FrameHeaderTypeUint32:
+		return 4
+	}
+	panic(ErrConnectionPanic)
+}
+
+func (fc *framedConnection) isTooLarge(n uint64) bool {
+	if n > math.MaxInt32 {
+		return true
+	}
+	if fc.option.MaxMessageSize > 0 && n > uint64(fc.option.MaxMessageSize) {
+		return true
+	}
+	switch fc.option.HeaderType {
+	case FrameHeaderTypeUint8:
+		return n > 0xFF
+	case FrameHeaderTypeUint16:
+		return n > 0xFFFF
+	case FrameHeaderTypeUint24:
+		return n > 0xFFFFFF
+	}
+	return false
+}
+
+// closeWithError 如果底层是本包的 connection ，则错误可以通过 Done 传递给上层
+func (fc *framedConnection) closeWithError(err error) {
+	if c, ok := fc.Connection.(*connection); ok {
+		c.close(err)
+		return
+	}
+	_ = fc.Close()
+}
@@ -0,0 +1,136 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/connection"
+)
+
+func TestFramedConnection(t *testing.T) {
+	golden := [][]byte{
+		[]byte("hello"),
+		{},
+		bytes.Repeat([]byte{'a'}, 300),
+		bytes.Repeat([]byte{'b'}, 70000),
+	}
+
+	vector := []struct {
+		headerType     connection.FrameHeaderType
+		isLittleEndian bool
+		writeChanSize  int
+		goldenNum      int
+	}{
+		{connection.FrameHeaderTypeUint8, false, 0, 2},
+		{connection.FrameHeaderTypeUint16, false, 0, 3},
+		{connection.FrameHeaderTypeUint16, true, 0, 3},
+		{connection.FrameHeaderTypeUint24, false, 0, 4},
+		{connection.FrameHeaderTypeUint24, true, 128, 4},
+		{connection.FrameHeaderTypeUint32, false, 128, 4},
+		{connection.FrameHeaderTypeUint32, true, 0, 4},
+		{connection.FrameHeaderTypeVarint, false, 128, 4},
+	}
+
+	for i := range vector {
+		v := vector[i]
+		testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+			modFramedOption := func(option *connection.FramedOption) {
+				option.HeaderType = v.headerType
+				option.IsLittleEndian = v.isLittleEndian
+			}
+			sc := connection.NewFramedConnection(connection.New(srvConn, func(option *connection.Option) {
+				option.ReadBufSize = 1024
+			}), modFramedOption)
+			cc := connection.NewFramedConnection(connection.New(cliConn, func(option *connection.Option) {
+				option.WriteChanSize = v.writeChanSize
+			}), modFramedOption)
+
+			go func() {
+				for i := 0; i < v.goldenNum; i++ {
+					err := cc.WriteMessage(golden[i])
+					assert.Equal(t, nil, err)
+				}
+			}()
+			for i := 0; i < v.goldenNum; i++ {
+				b, err := sc.ReadMessage()
+				assert.Equal(t, nil, err)
+				assert.Equal(t, golden[i], b)
+			}
+
+			cc.Close()
+			sc.Close()
+		})
+	}
+}
+
+func TestFramedConnection_TooLarge(t *testing.T) {
+	testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+		sc := connection.NewFramedConnection(connection.New(srvConn), func(option *connection.FramedOption) {
+			option.MaxMessageSize = 8
+		})
+		cc := connection.NewFramedConnection(connection.New(cliConn))
+
+		// 发送端超过长度头能表示的最大值
+		c8 := connection.NewFramedConnection(connection.New(cliConn), func(option *connection.FramedOption) {
+			option.HeaderType = connection.FrameHeaderTypeUint8
+		})
+		err := c8.WriteMessage(make([]byte, 256))
+		assert.Equal(t, connection.ErrMessageTooLarge, err)
+
+		// 接收端超过 MaxMessageSize
+		err = cc.WriteMessage(make([]byte, 9))
+		assert.Equal(t, nil, err)
+		b, err := sc.ReadMessage()
+		assert.Equal(t, nil, b)
+		assert.Equal(t, connection.ErrMessageTooLarge, err)
+		assert.Equal(t, connection.ErrMessageTooLarge, <-sc.Done())
+
+		cc.Close()
+	})
+}
+
+func TestFramedConnection_HostileHeader(t *testing.T) {
+	vector := []struct {
+		headerType     connection.FrameHeaderType
+		maxMessageSize int
+		header         []byte
+	}{
+		// 接近2^64的varint
+		{connection.FrameHeaderTypeVarint, 0, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01}},
+		// 超过64位的varint
+		{connection.FrameHeaderTypeVarint, 0, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x02}},
+		// 超过10字节的varint
+		{connection.FrameHeaderTypeVarint, 0, bytes.Repeat([]byte{0x80}, 11)},
+		// 默认的 MaxMessageSize
+		{connection.FrameHeaderTypeUint32, 16 * 1024 * 1024, []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+		// 不限制 MaxMessageSize 时，也不超过 math.MaxInt32
+		{connection.FrameHeaderTypeUint32, 0, []byte{0x80, 0x00, 0x00, 0x00}},
+	}
+	for i := range vector {
+		v := vector[i]
+		testWithConnPair(t, func(srvConn, cliConn net.Conn) {
+			sc := connection.NewFramedConnection(connection.New(srvConn), func(option *connection.FramedOption) {
+				option.HeaderType = v.headerType
+				option.MaxMessageSize = v.maxMessageSize
+			})
+			go func() {
+				_, _ = cliConn.Write(v.header)
+			}()
+			b, err := sc.ReadMessage()
+			assert.Equal(t, nil, b)
+			assert.Equal(t, connection.ErrMessageTooLarge, err)
+			assert.Equal(t, connection.ErrMessageTooLarge, <-sc.Done())
+			_ = cliConn.Close()
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrIdleTimeout = errors.New("naza.connection: idle timeout")
+
+type IdleType int
+
+const (
+	IdleTypeRead  IdleType = iota + 1 // 超过 Option.ReadIdleMs 没有读到数据
+	IdleTypeWrite                     // 超过 Option.WriteIdleMs 没有写出数据
+	IdleTypeAll                       // 超过 Option.AllIdleMs 既没有读到也没有写出数据
+)
+
+func (t IdleType) String() string {
+	switch t {
+	case IdleTypeRead:
+		return "read"
+	case IdleTypeWrite:
+		return "write"
+	case IdleTypeAll:
+		return "all"
+	}
+	return "unknown"
+}
+
+// idleChecker 每种空闲类型，在每个空闲周期内最多触发一次
+type idleChecker struct {
+	t         IdleType
+	d         time.Duration
+	lastFired time.Time
+}
+
+func (c *connection) isIdleEnabled() bool {
+	return c.option.ReadIdleMs > 0 || c.option.WriteIdleMs > 0 || c.option.AllIdleMs > 0
+}
+
+func (c *connection) runIdleLoop() {
+	var checkers []*idleChecker
+	add := func(t IdleType, ms int) {
+		if ms > 0 {
+			checkers = append(checkers, &idleChecker{t: t, d: time.Duration(ms) * time.Millisecond, lastFired: c.createTime})
+		}
+	}
+	add(IdleTypeRead, c.option.ReadIdleMs)
+	add(IdleTypeWrite, c.option.WriteIdleMs)
+	add(IdleTypeAll, c.option.AllIdleMs)
+
+	// 注意，每次都新建定时器，因为 mock 的定时器触发后不能再 Reset
+	d := c.checkIdle(checkers)
+	for {
+		timer := c.option.Clock.NewTimer(d)
+		select {
+		case <-c.closedChan:
+			timer.Stop()
+			return
+		case <-timer.C:
+			d = c.checkIdle(checkers)
+		}
+	}
+}
+
+// checkIdle 检查并触发空闲事件
+//
+// @return 距离下次需要检查的时长
+func (c *connection) checkIdle(checkers []*idleChecker) time.Duration {
+	now := c.option.Clock.Now()
+	lastRead := c.lastActiveTime(c.stat.LastReadUnixNano.Load())
+	lastWrite := c.lastActiveTime(c.stat.LastWriteUnixNano.Load())
+
+	var next time.Duration
+	for _, checker := range checkers {
+		var last time.Time
+		switch checker.t {
+		case IdleTypeRead:
+			last = lastRead
+		case IdleTypeWrite:
+			last = lastWrite
+		case IdleTypeAll:
+			last = lastRead
+			if lastWrite.After(last) {
+				last = lastWrite
+			}
+		}
+		if checker.lastFired.After(last) {
+			last = checker.lastFired
+		}
+
+		remain := last.Add(checker.d).Sub(now)
+		if remain <= 0 {
+			checker.lastFired = now
+			c.onIdle(checker.t)
+			remain = checker.d
+		}
+		if next == 0 || remain < next {
+			next = remain
+		}
+	}
+	return next
+}
+
+func (c *connection) onIdle(t IdleType) {
+	if c.closedFlag.Load() {
+		return
+	}
+	if c.option.OnIdle != nil {
+		c.option.OnIdle(c, t)
+	}
+	if c.option.IsCloseOnIdle {
+		c.close(ErrIdleTimeout)
+	}
+}
+
+func (c *connection) lastActiveTime(unixNano int64) time.Time {
+	if unixNano == 0 {
+		return c.createTime
+	}
+	return time.Unix(0, unixNano)
+}
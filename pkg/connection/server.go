@@ -0,0 +1,281 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/q191201771/naza/pkg/nazaatomic"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+var (
+	ErrServerClosed          = errors.New("naza.connection: server closed")
+	ErrServerShutdownTimeout = errors.New("naza.connection: server shutdown timeout")
+)
+
+// OnConnection 接收到新连接时的回调
+//
+// 每个连接的回调在独立的协程中执行，一般在回调中循环读取连接上的数据，
+// 注意，回调返回后，Server 将关闭该连接，并不再管理它
+type OnConnection func(c Connection)
+
+type ServerOption struct {
+	// 最大连接数，如果为0则不限制
+	// 达到最大连接数时，新接收的连接将被直接关闭
+	MaxConnNum int
+
+	// 所有连接共用的配置，接收到新连接时，用于调用 New
+	ConnectionModOptions []ModOption
+}
+
+var defaultServerOption = ServerOption{
+	MaxConnNum:           0,
+	ConnectionModOptions: nil,
+}
+
+type ModServerOption func(option *ServerOption)
+
+const (
+	minAcceptRetryDelay = 5 * time.Millisecond
+	maxAcceptRetryDelay = time.Second
+)
+
+type ServerStat struct {
+	ConnNum     int    // 当前连接数
+	AcceptedNum uint64 // 累计接收并管理的连接数
+	RejectedNum uint64 // 由于达到最大连接数而被关闭的连接数
+
+	// 当前所有连接 Stat 的累加值，其中 LastReadTime 和 LastWriteTime 取最大值，Age 无意义
+	ConnStat Stat
+}
+
+// Server 接收连接，并将 net.Conn 封装成 Connection 后统一管理
+type Server struct {
+	option       ServerOption
+	onConnection OnConnection
+
+	mu        sync.Mutex
+	ln        net.Listener
+	conns     map[string]Connection
+	closeFlag bool
+	wg        sync.WaitGroup // 用于等待所有连接的回调返回
+
+	acceptedNum nazaatomic.Uint64
+	rejectedNum nazaatomic.Uint64
+}
+
+func NewServer(onConnection OnConnection, modOptions ...ModServerOption) *Server {
+	s := &Server{
+		option:       defaultServerOption,
+		onConnection: onConnection,
+		conns:        make(map[string]Connection),
+	}
+	for _, fn := range modOptions {
+		fn(&s.option)
+	}
+	return s
+}
+
+// Serve 阻塞接收`ln`上的连接，直到`ln`出错或者调用了 Shutdown
+//
+// 和 net/http 一样，Accept 返回临时错误（比如文件描述符耗尽）时，等待一段时间后重试，不返回
+//
+// @return 调用 Shutdown 导致的返回为 ErrServerClosed
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closeFlag {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.ln = ln
+	s.mu.Unlock()
+
+	var tempDelay time.Duration // Accept 返回临时错误时，重试前等待的时长
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closeFlag := s.closeFlag
+			s.mu.Unlock()
+			if closeFlag {
+				return ErrServerClosed
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				if tempDelay == 0 {
+					tempDelay = minAcceptRetryDelay
+				} else {
+					tempDelay *= 2
+				}
+				if tempDelay > maxAcceptRetryDelay {
+					tempDelay = maxAcceptRetryDelay
+				}
+				nazalog.Warnf("accept error, retrying in %v. err=%+v", tempDelay, err)
+				time.Sleep(tempDelay)
+				continue
+			}
+			return err
+		}
+		tempDelay = 0
+		s.handleConn(conn)
+	}
+}
+
+// Get 根据 Connection.UniqueKey 获取连接，不存在返回nil
+func (s *Server) Get(uniqueKey string) Connection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns[uniqueKey]
+}
+
+// Range 遍历所有连接，`fn`返回false时停止遍历
+//
+// 注意，`fn`中不应调用 Server 的其他方法
+func (s *Server) Range(fn func(c Connection) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		if !fn(c) {
+			return
+		}
+	}
+}
+
+// Count 当前连接数
+func (s *Server) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// Broadcast 向所有连接发送`b`
+//
+// 注意，如果连接使用了异步发送，`b`会被所有连接的发送队列共同持有，上层不应再修改`b`的内容
+//
+// @return 发送成功的连接数
+func (s *Server) Broadcast(b []byte) (succNum int) {
+	for _, c := range s.snapshot() {
+		if _, err := c.Write(b); err == nil {
+			succNum++
+		}
+	}
+	return
+}
+
+func (s *Server) GetStat() (stat ServerStat) {
+	stat.AcceptedNum = s.acceptedNum.Load()
+	stat.RejectedNum = s.rejectedNum.Load()
+
+	conns := s.snapshot()
+	stat.ConnNum = len(conns)
+	for _, c := range conns {
+		cs := c.GetStat()
+		stat.ConnStat.ReadBytesSum += cs.ReadBytesSum
+		stat.ConnStat.WroteBytesSum += cs.WroteBytesSum
+		stat.ConnStat.ReadBitrate += cs.ReadBitrate
+		stat.ConnStat.WroteBitrate += cs.WroteBitrate
+		stat.ConnStat.PendingWriteMsgNum += cs.PendingWriteMsgNum
+		stat.ConnStat.PendingWriteBytes += cs.PendingWriteBytes
+		stat.ConnStat.WriteChanFullCount += cs.WriteChanFullCount
+		stat.ConnStat.DropOldestCount += cs.DropOldestCount
+		stat.ConnStat.DropNewestCount += cs.DropNewestCount
+		stat.ConnStat.DropLowPriorityCount += cs.DropLowPriorityCount
+		if cs.LastReadTime.After(stat.ConnStat.LastReadTime) {
+			stat.ConnStat.LastReadTime = cs.LastReadTime
+		}
+		if cs.LastWriteTime.After(stat.ConnStat.LastWriteTime) {
+			stat.ConnStat.LastWriteTime = cs.LastWriteTime
+		}
+	}
+	return
+}
+
+// Shutdown 关闭 Server
+//
+// 不再接收新连接，关闭所有连接，并等待所有连接的 OnConnection 回调返回
+//
+// 注意，如果希望关闭前将连接上待发送的数据发送完毕，可以在调用 Shutdown 前通过 Range 对连接调用 Flush
+//
+// @param timeout 等待回调返回的最长时间，为0则一直等待
+//
+// @return 超时返回 ErrServerShutdownTimeout ，重复调用返回 ErrServerClosed
+func (s *Server) Shutdown(timeout time.Duration) error {
+	s.mu.Lock()
+	if s.closeFlag {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.closeFlag = true
+	ln := s.ln
+	s.mu.Unlock()
+
+	nazalog.Debugf("shutdown connection server. timeout=%v", timeout)
+	if ln != nil {
+		_ = ln.Close()
+	}
+	for _, c := range s.snapshot() {
+		_ = c.Close()
+	}
+
+	doneChan := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(doneChan)
+	}()
+	if timeout == 0 {
+		<-doneChan
+		return nil
+	}
+	select {
+	case <-doneChan:
+		return nil
+	case <-time.After(timeout):
+		return ErrServerShutdownTimeout
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	s.mu.Lock()
+	if s.closeFlag || (s.option.MaxConnNum > 0 && len(s.conns) >= s.option.MaxConnNum) {
+		s.mu.Unlock()
+		s.rejectedNum.Increment()
+		nazalog.Warnf("reject connection. remote=%s, max=%d", conn.RemoteAddr(), s.option.MaxConnNum)
+		_ = conn.Close()
+		return
+	}
+	c := New(conn, s.option.ConnectionModOptions...)
+	s.conns[c.UniqueKey()] = c
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	s.acceptedNum.Increment()
+
+	go func() {
+		defer s.wg.Done()
+		s.onConnection(c)
+		_ = c.Close()
+
+		s.mu.Lock()
+		delete(s.conns, c.UniqueKey())
+		s.mu.Unlock()
+	}()
+}
+
+func (s *Server) snapshot() []Connection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conns := make([]Connection, 0, len(s.conns))
+	for _, c := range s.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
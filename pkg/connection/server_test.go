@@ -0,0 +1,146 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection_test
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/connection"
+)
+
+func TestServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+
+	// 回调中一直读，直到连接关闭
+	s := connection.NewServer(func(c connection.Connection) {
+		b := make([]byte, 128)
+		for {
+			if _, err := c.Read(b); err != nil {
+				return
+			}
+		}
+	}, func(option *connection.ServerOption) {
+		option.MaxConnNum = 2
+		option.ConnectionModOptions = []connection.ModOption{func(option *connection.Option) {
+			option.WriteChanSize = 16
+		}}
+	})
+	serveErrChan := make(chan error, 1)
+	go func() {
+		serveErrChan <- s.Serve(ln)
+	}()
+
+	var clients []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		assert.Equal(t, nil, err)
+		clients = append(clients, conn)
+	}
+	for s.GetStat().AcceptedNum+s.GetStat().RejectedNum != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 2, s.Count())
+	stat := s.GetStat()
+	assert.Equal(t, 2, stat.ConnNum)
+	assert.Equal(t, uint64(2), stat.AcceptedNum)
+	assert.Equal(t, uint64(1), stat.RejectedNum)
+
+	var keys []string
+	s.Range(func(c connection.Connection) bool {
+		keys = append(keys, c.UniqueKey())
+		return true
+	})
+	assert.Equal(t, 2, len(keys))
+	assert.IsNotNil(t, s.Get(keys[0]))
+	assert.Equal(t, nil, s.Get("not exist"))
+
+	assert.Equal(t, 2, s.Broadcast([]byte("hello")))
+	var recvNum int
+	for _, conn := range clients {
+		b := make([]byte, 5)
+		_ = conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if _, err := io.ReadFull(conn, b); err == nil {
+			assert.Equal(t, []byte("hello"), b)
+			recvNum++
+		}
+	}
+	assert.Equal(t, 2, recvNum)
+	for s.GetStat().ConnStat.WroteBytesSum != 10 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 客户端主动关闭，服务端回调返回后连接被移除
+	_ = clients[0].Close()
+	for s.Count() == 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Equal(t, nil, s.Shutdown(time.Second))
+	assert.Equal(t, connection.ErrServerClosed, <-serveErrChan)
+	assert.Equal(t, 0, s.Count())
+	assert.Equal(t, connection.ErrServerClosed, s.Shutdown(time.Second))
+
+	for _, conn := range clients {
+		_ = conn.Close()
+	}
+}
+
+type tempError struct{}
+
+func (tempError) Error() string   { return "temporary error" }
+func (tempError) Timeout() bool   { return false }
+func (tempError) Temporary() bool { return true }
+
+// errListener 前`tempNum`次 Accept 返回临时错误，之后返回`ln`的结果
+type errListener struct {
+	net.Listener
+	tempNum int
+}
+
+func (l *errListener) Accept() (net.Conn, error) {
+	if l.tempNum > 0 {
+		l.tempNum--
+		return nil, tempError{}
+	}
+	return l.Listener.Accept()
+}
+
+func TestServer_AcceptTemporaryError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+
+	connChan := make(chan connection.Connection, 1)
+	s := connection.NewServer(func(c connection.Connection) {
+		connChan <- c
+		<-c.Done()
+	})
+	serveErrChan := make(chan error, 1)
+	go func() {
+		serveErrChan <- s.Serve(&errListener{Listener: ln, tempNum: 3})
+	}()
+
+	// 临时错误后依然可以接收连接
+	cliConn, err := net.Dial("tcp", ln.Addr().String())
+	assert.Equal(t, nil, err)
+	<-connChan
+	assert.Equal(t, 1, s.Count())
+
+	// 其他错误时返回
+	_ = ln.Close()
+	err = <-serveErrChan
+	assert.Equal(t, true, err != nil && err != connection.ErrServerClosed)
+
+	_ = cliConn.Close()
+	assert.Equal(t, nil, s.Shutdown(0))
+}
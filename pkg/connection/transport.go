@@ -0,0 +1,186 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io"
+	"net"
+	"time"
+)
+
+// DialTcp 建立tcp连接并封装成 Connection
+//
+// @param timeoutMs 连接超时，单位毫秒，为0则不设置超时
+func DialTcp(addr string, timeoutMs int, modOptions ...ModOption) (Connection, error) {
+	return dial("tcp", addr, timeoutMs, modOptions...)
+}
+
+// DialUnix 建立unix domain socket连接并封装成 Connection
+//
+// @param path socket文件路径
+func DialUnix(path string, timeoutMs int, modOptions ...ModOption) (Connection, error) {
+	return dial("unix", path, timeoutMs, modOptions...)
+}
+
+// DialTls 建立tls连接并封装成 Connection ，函数返回前已完成tls握手
+//
+// 握手协商出的ALPN等信息可通过 TlsConnectionState 获取
+//
+// @param config 如果需要ALPN，设置 config.NextProtos
+func DialTls(addr string, config *tls.Config, timeoutMs int, modOptions ...ModOption) (Connection, error) {
+	dialer := &net.Dialer{Timeout: time.Duration(timeoutMs) * time.Millisecond}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return New(conn, modOptions...), nil
+}
+
+// NewTlsServerConfig 加载证书和私钥文件，生成服务端使用的tls配置
+//
+// @param nextProtos 支持的ALPN协议列表，比如"h2", "http/1.1"，不需要则不传
+func NewTlsServerConfig(certFile, keyFile string, nextProtos ...string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   nextProtos,
+	}, nil
+}
+
+// TlsConnectionState 获取tls连接的状态，比如协商出的ALPN协议 NegotiatedProtocol
+//
+// @return 如果`c`的底层连接不是tls连接，第二个返回值为false
+func TlsConnectionState(c Connection) (tls.ConnectionState, bool) {
+	cc, ok := c.(*connection)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+	tlsConn, ok := cc.Conn.(*tls.Conn)
+	if !ok {
+		return tls.ConnectionState{}, false
+	}
+	return tlsConn.ConnectionState(), true
+}
+
+// Listener 对 net.Listener 的封装，Accept 返回的是 Connection
+type Listener struct {
+	ln         net.Listener
+	modOptions []ModOption
+}
+
+// ListenTcp
+//
+// @param modOptions Accept 返回的所有 Connection 使用的配置
+func ListenTcp(addr string, modOptions ...ModOption) (*Listener, error) {
+	return listen("tcp", addr, modOptions...)
+}
+
+// ListenUnix
+//
+// 注意，如果`path`对应的socket文件已经存在，将返回错误，需要上层自行删除
+func ListenUnix(path string, modOptions ...ModOption) (*Listener, error) {
+	return listen("unix", path, modOptions...)
+}
+
+// ListenTls
+//
+// 注意，tls握手在 Accept 返回的 Connection 首次读写时进行
+func ListenTls(addr string, config *tls.Config, modOptions ...ModOption) (*Listener, error) {
+	ln, err := tls.Listen("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+	return NewListener(ln, modOptions...), nil
+}
+
+func NewListener(ln net.Listener, modOptions ...ModOption) *Listener {
+	return &Listener{
+		ln:         ln,
+		modOptions: modOptions,
+	}
+}
+
+func (l *Listener) Accept() (Connection, error) {
+	conn, err := l.ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return New(conn, l.modOptions...), nil
+}
+
+func (l *Listener) Close() error {
+	return l.ln.Close()
+}
+
+func (l *Listener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// NetListener 获取底层的 net.Listener ，比如用于 Server.Serve
+func (l *Listener) NetListener() net.Listener {
+	return l.ln
+}
+
+func dial(network, addr string, timeoutMs int, modOptions ...ModOption) (Connection, error) {
+	conn, err := net.DialTimeout(network, addr, time.Duration(timeoutMs)*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	return New(conn, modOptions...), nil
+}
+
+func listen(network, addr string, modOptions ...ModOption) (*Listener, error) {
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewListener(ln, modOptions...), nil
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// 底层不支持writev时，总大小不超过该值的多个内存块合并后再发送
+const mergeBuffersMaxSize = 64 * 1024
+
+// isVectoredWriter `w`是否支持writev或者自身带缓冲，此时可以直接使用 net.Buffers.WriteTo
+func isVectoredWriter(w io.Writer) bool {
+	switch w.(type) {
+	case *net.TCPConn, *net.UnixConn, *bufio.Writer:
+		return true
+	}
+	return false
+}
+
+// writeBuffers 对于不支持writev的底层连接（比如 *tls.Conn），net.Buffers.WriteTo 会对每个内存块调用一次 Write ，
+// 带来更多的系统调用，对于tls还会产生更多的record，所以小块数据先合并成一块再发送
+func writeBuffers(w io.Writer, b net.Buffers) (int64, error) {
+	if isVectoredWriter(w) {
+		return b.WriteTo(w)
+	}
+
+	var total int
+	for _, v := range b {
+		total += len(v)
+	}
+	if len(b) <= 1 || total > mergeBuffersMaxSize {
+		return b.WriteTo(w)
+	}
+
+	merged := make([]byte, 0, total)
+	for _, v := range b {
+		merged = append(merged, v...)
+	}
+	n, err := w.Write(merged)
+	return int64(n), err
+}
@@ -0,0 +1,136 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/connection"
+)
+
+func TestTcp(t *testing.T) {
+	l, err := connection.ListenTcp("127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	defer l.Close()
+
+	testListenerDial(t, l, func() (connection.Connection, error) {
+		return connection.DialTcp(l.Addr().String(), 1000)
+	})
+}
+
+func TestUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nazaconn")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.sock")
+
+	l, err := connection.ListenUnix(path)
+	assert.Equal(t, nil, err)
+	defer l.Close()
+
+	testListenerDial(t, l, func() (connection.Connection, error) {
+		return connection.DialUnix(path, 1000)
+	})
+}
+
+func TestTls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nazaconn")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := genSelfSignedCert(t, dir)
+
+	config, err := connection.NewTlsServerConfig(certFile, keyFile, "nazaproto")
+	assert.Equal(t, nil, err)
+	l, err := connection.ListenTls("127.0.0.1:0", config)
+	assert.Equal(t, nil, err)
+	defer l.Close()
+
+	var cliConn connection.Connection
+	testListenerDial(t, l, func() (connection.Connection, error) {
+		cliConn, err = connection.DialTls(l.Addr().String(), &tls.Config{
+			InsecureSkipVerify: true,
+			NextProtos:         []string{"nazaproto"},
+		}, 1000)
+		return cliConn, err
+	})
+
+	state, ok := connection.TlsConnectionState(cliConn)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "nazaproto", state.NegotiatedProtocol)
+
+	_, err = connection.NewTlsServerConfig(certFile+".notexist", keyFile)
+	assert.IsNotNil(t, err)
+}
+
+// testListenerDial 建立连接，客户端使用 Writev 发送，服务端读取并校验
+//
+// 注意，服务端需要在独立协程中读取，因为tls的客户端握手需要服务端配合
+func testListenerDial(t *testing.T, l *connection.Listener, dial func() (connection.Connection, error)) {
+	doneChan := make(chan struct{})
+	go func() {
+		defer close(doneChan)
+		c, err := l.Accept()
+		assert.Equal(t, nil, err)
+		b := make([]byte, 11)
+		_, err = c.ReadAtLeast(b, 11)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []byte("hello world"), b)
+		_ = c.Close()
+	}()
+
+	c, err := dial()
+	assert.Equal(t, nil, err)
+	n, err := c.Writev(net.Buffers{[]byte("he"), []byte("llo"), []byte(" world")})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 11, n)
+	assert.Equal(t, uint64(11), c.GetStat().WroteBytesSum)
+
+	<-doneChan
+	_ = c.Close()
+}
+
+func genSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"naza"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	assert.Equal(t, nil, err)
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	assert.Equal(t, nil, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	assert.Equal(t, nil, err)
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	assert.Equal(t, nil, err)
+	return
+}
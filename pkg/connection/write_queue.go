@@ -0,0 +1,139 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package connection
+
+import "sync"
+
+// writeQueue 异步发送队列
+//
+// 没有直接使用 channel ，是因为队列满时的部分丢弃策略需要访问、移除队列中间的元素
+type writeQueue struct {
+	capacity int
+
+	mu       sync.Mutex
+	msgs     []wMsg
+	bytes    int64
+	disposed bool // 发送协程已退出，之后入队的消息直接释放
+
+	readyChan chan struct{} // 有消息入队时通知发送协程
+	spaceChan chan struct{} // 有消息出队时通知阻塞等待入队的协程
+}
+
+func newWriteQueue(capacity int) *writeQueue {
+	return &writeQueue{
+		capacity:  capacity,
+		readyChan: make(chan struct{}, 1),
+		spaceChan: make(chan struct{}, 1),
+	}
+}
+
+// tryPush 队列满时不入队，返回false
+//
+// 注意，flush类型的消息不占用队列容量，总是可以入队
+func (q *writeQueue) tryPush(msg wMsg) bool {
+	q.mu.Lock()
+	if q.disposed {
+		q.mu.Unlock()
+		releaseMsg(msg)
+		return true
+	}
+	if msg.t != wMsgTypeFlush && len(q.msgs) >= q.capacity {
+		q.mu.Unlock()
+		return false
+	}
+	q.pushWithLock(msg)
+	full := len(q.msgs) >= q.capacity
+	q.mu.Unlock()
+
+	q.notify(q.readyChan)
+	if !full {
+		// 可能还有其他协程在等待入队，接力唤醒
+		q.notify(q.spaceChan)
+	}
+	return true
+}
+
+// pushWithDrop 队列满时，通过`pick`选择一条消息丢弃后入队
+//
+// @param pick 参数为队列中的消息以及新消息，返回被丢弃消息在队列中的位置，返回-1表示丢弃新消息，
+// 注意，`pick`不应该选择flush类型的消息
+//
+// @return 被丢弃的消息，以及是否有消息被丢弃
+func (q *writeQueue) pushWithDrop(msg wMsg, pick func(msgs []wMsg, msg wMsg) int) (dropped wMsg, isDropped bool) {
+	q.mu.Lock()
+	if q.disposed {
+		q.mu.Unlock()
+		releaseMsg(msg)
+		return
+	}
+	if len(q.msgs) >= q.capacity {
+		i := pick(q.msgs, msg)
+		if i < 0 {
+			q.mu.Unlock()
+			return msg, true
+		}
+		dropped, isDropped = q.msgs[i], true
+		q.bytes -= int64(dropped.size)
+		q.msgs = append(q.msgs[:i], q.msgs[i+1:]...)
+	}
+	q.pushWithLock(msg)
+	q.mu.Unlock()
+
+	q.notify(q.readyChan)
+	return
+}
+
+func (q *writeQueue) pop() (msg wMsg, ok bool) {
+	q.mu.Lock()
+	if len(q.msgs) == 0 {
+		q.mu.Unlock()
+		return
+	}
+	msg, ok = q.msgs[0], true
+	q.msgs[0] = wMsg{}
+	q.msgs = q.msgs[1:]
+	q.bytes -= int64(msg.size)
+	q.mu.Unlock()
+
+	q.notify(q.spaceChan)
+	return
+}
+
+// dispose 发送协程退出时调用，释放队列中剩余的消息
+func (q *writeQueue) dispose() {
+	q.mu.Lock()
+	msgs := q.msgs
+	q.msgs = nil
+	q.bytes = 0
+	q.disposed = true
+	q.mu.Unlock()
+
+	for _, msg := range msgs {
+		releaseMsg(msg)
+	}
+}
+
+// status 队列中的消息数和字节数
+func (q *writeQueue) status() (num int, bytes int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.msgs), q.bytes
+}
+
+func (q *writeQueue) pushWithLock(msg wMsg) {
+	q.msgs = append(q.msgs, msg)
+	q.bytes += int64(msg.size)
+}
+
+func (q *writeQueue) notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package fake
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/q191201771/naza/pkg/mock"
+)
+
+var (
+	ErrFakeConnClosed = errors.New("naza.fake: use of closed connection")
+	ErrFakeConnReset  = errors.New("naza.fake: connection reset by peer")
+)
+
+// ErrFakeConnTimeout 读写超过 deadline 时返回，实现了 net.Error ，Timeout 返回true
+var ErrFakeConnTimeout net.Error = timeoutError{}
+
+type ConnOption struct {
+	// 时间源，延迟、带宽以及 deadline 的计算都基于它
+	// 使用 mock.NewFakeClock 时，只有调用 Clock.Add 或 Clock.Set 推进时间，数据才会到达，deadline 才会超时
+	Clock mock.Clock
+
+	// 写入的数据经过多久后对端才能读到，单位毫秒
+	LatencyMs int
+
+	// 带宽，单位字节/秒，为0则不限制
+	BytesPerSec int
+
+	// 如果不为0，则单次 Read 最多返回的字节数，用于模拟 short read
+	MaxReadSize int
+
+	// 如果不为0，则单次 Write 最多写入的字节数，超出时写入部分数据并返回 io.ErrShortWrite ，用于模拟 short write
+	MaxWriteSize int
+}
+
+var defaultConnOption = ConnOption{
+	Clock:        mock.NewStdClock(),
+	LatencyMs:    0,
+	BytesPerSec:  0,
+	MaxReadSize:  0,
+	MaxWriteSize: 0,
+}
+
+type ModConnOption func(option *ConnOption)
+
+// NewConnPair 创建一对在内存中互相连接的 net.Conn ，可以模拟延迟、带宽、short read/write、连接重置、超时等情况
+//
+// 与 net.Pipe 不同，写入不会等待对端读取（没有发送缓冲区大小的限制），除非调用了 Conn.SetWriteBlocked
+//
+// @param modOptions 两端共用的配置
+func NewConnPair(modOptions ...ModConnOption) (*Conn, *Conn) {
+	option := defaultConnOption
+	for _, fn := range modOptions {
+		fn(&option)
+	}
+
+	p1 := newConnPipe()
+	p2 := newConnPipe()
+	c1 := &Conn{option: option, r: p1, w: p2, local: connAddr("fake:1"), remote: connAddr("fake:2")}
+	c2 := &Conn{option: option, r: p2, w: p1, local: connAddr("fake:2"), remote: connAddr("fake:1")}
+	return c1, c2
+}
+
+// Conn 实现了 net.Conn
+type Conn struct {
+	option ConnOption
+	r      *connPipe // 读取对端写入的数据
+	w      *connPipe // 写入的数据供对端读取
+
+	local  net.Addr
+	remote net.Addr
+}
+
+func (c *Conn) Read(b []byte) (n int, err error) {
+	p := c.r
+	p.mu.Lock()
+	for {
+		if p.readerClosed {
+			p.mu.Unlock()
+			return 0, ErrFakeConnClosed
+		}
+		if p.err != nil {
+			p.mu.Unlock()
+			return 0, p.err
+		}
+		now := c.option.Clock.Now()
+		if isExceeded(p.readDeadline, now) {
+			p.mu.Unlock()
+			return 0, ErrFakeConnTimeout
+		}
+		if len(p.chunks) == 0 && p.writerClosed {
+			p.mu.Unlock()
+			return 0, io.EOF
+		}
+
+		if len(p.chunks) > 0 && !p.chunks[0].arrival.After(now) {
+			n = c.readArrivedWithLock(b, now)
+			p.mu.Unlock()
+			return n, nil
+		}
+
+		// 等待数据到达、deadline 到期，或者状态变化
+		var wakeAt time.Time
+		if len(p.chunks) > 0 {
+			wakeAt = p.chunks[0].arrival
+		}
+		if !p.readDeadline.IsZero() && (wakeAt.IsZero() || p.readDeadline.Before(wakeAt)) {
+			wakeAt = p.readDeadline
+		}
+		p.waitWithLock(c.option.Clock, wakeAt)
+	}
+}
+
+func (c *Conn) Write(b []byte) (n int, err error) {
+	p := c.w
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.writerClosed {
+			return 0, ErrFakeConnClosed
+		}
+		if p.err != nil {
+			return 0, p.err
+		}
+		if p.readerClosed {
+			return 0, io.ErrClosedPipe
+		}
+		now := c.option.Clock.Now()
+		if isExceeded(p.writeDeadline, now) {
+			return 0, ErrFakeConnTimeout
+		}
+		if !p.writeBlocked {
+			break
+		}
+		p.waitWithLock(c.option.Clock, p.writeDeadline)
+	}
+
+	n = len(b)
+	if c.option.MaxWriteSize > 0 && n > c.option.MaxWriteSize {
+		n = c.option.MaxWriteSize
+		err = io.ErrShortWrite
+	}
+	if n == 0 {
+		return n, err
+	}
+
+	// 数据先按带宽依次发送完毕，再经过固定延迟到达对端
+	now := c.option.Clock.Now()
+	sendEnd := now
+	if p.linkFreeAt.After(sendEnd) {
+		sendEnd = p.linkFreeAt
+	}
+	if c.option.BytesPerSec > 0 {
+		sendEnd = sendEnd.Add(time.Duration(int64(n) * int64(time.Second) / int64(c.option.BytesPerSec)))
+	}
+	p.linkFreeAt = sendEnd
+
+	buf := make([]byte, n)
+	copy(buf, b)
+	p.chunks = append(p.chunks, connChunk{
+		b:       buf,
+		arrival: sendEnd.Add(time.Duration(c.option.LatencyMs) * time.Millisecond),
+	})
+	p.broadcastWithLock()
+	return n, err
+}
+
+// Close 对端读完剩余数据后将读到 io.EOF ，对端继续写将返回 io.ErrClosedPipe
+func (c *Conn) Close() error {
+	c.r.mu.Lock()
+	c.r.readerClosed = true
+	c.r.broadcastWithLock()
+	c.r.mu.Unlock()
+
+	c.w.mu.Lock()
+	c.w.writerClosed = true
+	c.w.broadcastWithLock()
+	c.w.mu.Unlock()
+	return nil
+}
+
+// Reset 模拟连接被重置，两端之后的读写都立即返回 ErrFakeConnReset ，未读取的数据被丢弃
+func (c *Conn) Reset() {
+	for _, p := range []*connPipe{c.r, c.w} {
+		p.mu.Lock()
+		p.err = ErrFakeConnReset
+		p.chunks = nil
+		p.broadcastWithLock()
+		p.mu.Unlock()
+	}
+}
+
+// SetWriteBlocked 模拟对端不读取、发送缓冲区已满的情况，设置为true后 Write 将阻塞，直到设置为false、超时或连接关闭
+func (c *Conn) SetWriteBlocked(blocked bool) {
+	c.w.mu.Lock()
+	c.w.writeBlocked = blocked
+	c.w.broadcastWithLock()
+	c.w.mu.Unlock()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	_ = c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.r.mu.Lock()
+	c.r.readDeadline = t
+	c.r.broadcastWithLock()
+	c.r.mu.Unlock()
+	return nil
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.w.mu.Lock()
+	c.w.writeDeadline = t
+	c.w.broadcastWithLock()
+	c.w.mu.Unlock()
+	return nil
+}
+
+func (c *Conn) readArrivedWithLock(b []byte, now time.Time) (n int) {
+	p := c.r
+	max := len(b)
+	if c.option.MaxReadSize > 0 && max > c.option.MaxReadSize {
+		max = c.option.MaxReadSize
+	}
+	for n < max && len(p.chunks) > 0 && !p.chunks[0].arrival.After(now) {
+		m := copy(b[n:max], p.chunks[0].b)
+		n += m
+		if m == len(p.chunks[0].b) {
+			p.chunks = p.chunks[1:]
+		} else {
+			p.chunks[0].b = p.chunks[0].b[m:]
+		}
+	}
+	return n
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// connPipe 单向的数据通道
+type connPipe struct {
+	mu            sync.Mutex
+	chunks        []connChunk
+	linkFreeAt    time.Time // 之前写入的数据按带宽全部发送完毕的时间点
+	readerClosed  bool
+	writerClosed  bool
+	writeBlocked  bool
+	err           error
+	readDeadline  time.Time
+	writeDeadline time.Time
+	changed       chan struct{} // 状态变化时close并替换，用于唤醒阻塞中的读写
+}
+
+type connChunk struct {
+	b       []byte
+	arrival time.Time
+}
+
+func newConnPipe() *connPipe {
+	return &connPipe{
+		changed: make(chan struct{}),
+	}
+}
+
+// waitWithLock 释放锁等待，直到状态变化或者到达`wakeAt`，返回前重新获取锁
+//
+// @param wakeAt 为零值时只等待状态变化
+func (p *connPipe) waitWithLock(clock mock.Clock, wakeAt time.Time) {
+	changed := p.changed
+	var timerC <-chan time.Time
+	if !wakeAt.IsZero() {
+		timer := clock.NewTimer(wakeAt.Sub(clock.Now()))
+		defer timer.Stop()
+		// 创建定时器前时间可能已经被推进，此时 mock 的定时器不会再触发，所以创建后再检查一次
+		if !clock.Now().Before(wakeAt) {
+			return
+		}
+		timerC = timer.C
+	}
+	p.mu.Unlock()
+	select {
+	case <-changed:
+	case <-timerC:
+	}
+	p.mu.Lock()
+}
+
+func (p *connPipe) broadcastWithLock() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func isExceeded(deadline, now time.Time) bool {
+	return !deadline.IsZero() && !now.Before(deadline)
+}
+
+type connAddr string
+
+func (a connAddr) Network() string {
+	return "fake"
+}
+
+func (a connAddr) String() string {
+	return string(a)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string {
+	return "naza.fake: i/o timeout"
+}
+
+func (timeoutError) Timeout() bool {
+	return true
+}
+
+func (timeoutError) Temporary() bool {
+	return true
+}
@@ -0,0 +1,125 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package fake_test
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/fake"
+	"github.com/q191201771/naza/pkg/mock"
+)
+
+func TestConnPair(t *testing.T) {
+	c1, c2 := fake.NewConnPair()
+	var _ net.Conn = c1
+
+	n, err := c1.Write([]byte("hello"))
+	assert.Equal(t, 5, n)
+	assert.Equal(t, nil, err)
+	b := make([]byte, 16)
+	n, err = c2.Read(b)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []byte("hello"), b[:n])
+	assert.Equal(t, "fake:1", c2.RemoteAddr().String())
+
+	// 关闭后对端读到EOF，对端写失败
+	_, _ = c2.Write([]byte("world"))
+	assert.Equal(t, nil, c2.Close())
+	n, err = c2.Read(b)
+	assert.Equal(t, fake.ErrFakeConnClosed, err)
+	n, err = c1.Read(b)
+	assert.Equal(t, []byte("world"), b[:n])
+	_, err = c1.Read(b)
+	assert.Equal(t, io.EOF, err)
+	_, err = c1.Write(b)
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
+
+func TestConnPair_LatencyAndBandwidth(t *testing.T) {
+	clock := mock.NewFakeClock()
+	clock.Set(time.Unix(1600000000, 0))
+	c1, c2 := fake.NewConnPair(func(option *fake.ConnOption) {
+		option.Clock = clock
+		option.LatencyMs = 100
+		option.BytesPerSec = 1000
+	})
+
+	// 100字节需要100毫秒发送，再经过100毫秒延迟到达
+	_, err := c1.Write(make([]byte, 100))
+	assert.Equal(t, nil, err)
+
+	readChan := make(chan int, 1)
+	go func() {
+		n, _ := c2.Read(make([]byte, 1024))
+		readChan <- n
+	}()
+	clock.Add(150 * time.Millisecond)
+	select {
+	case <-readChan:
+		t.Fatal("should not arrive")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Add(50 * time.Millisecond)
+	assert.Equal(t, 100, <-readChan)
+}
+
+func TestConnPair_Deadline(t *testing.T) {
+	clock := mock.NewFakeClock()
+	clock.Set(time.Unix(1600000000, 0))
+	c1, c2 := fake.NewConnPair(func(option *fake.ConnOption) {
+		option.Clock = clock
+	})
+
+	_ = c2.SetReadDeadline(clock.Now().Add(time.Second))
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := c2.Read(make([]byte, 16))
+		errChan <- err
+	}()
+	clock.Add(time.Second)
+	err := <-errChan
+	assert.Equal(t, fake.ErrFakeConnTimeout, err)
+	assert.Equal(t, true, err.(net.Error).Timeout())
+
+	c1.SetWriteBlocked(true)
+	_ = c1.SetWriteDeadline(clock.Now().Add(time.Second))
+	go func() {
+		_, err := c1.Write([]byte("hello"))
+		errChan <- err
+	}()
+	clock.Add(time.Second)
+	assert.Equal(t, fake.ErrFakeConnTimeout, <-errChan)
+}
+
+func TestConnPair_ShortAndReset(t *testing.T) {
+	c1, c2 := fake.NewConnPair(func(option *fake.ConnOption) {
+		option.MaxReadSize = 2
+		option.MaxWriteSize = 4
+	})
+	n, err := c1.Write([]byte("hello"))
+	assert.Equal(t, 4, n)
+	assert.Equal(t, io.ErrShortWrite, err)
+	b := make([]byte, 16)
+	n, err = c2.Read(b)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []byte("he"), b[:n])
+	n, err = c2.Read(b)
+	assert.Equal(t, []byte("ll"), b[:n])
+
+	c1.Reset()
+	_, err = c2.Read(b)
+	assert.Equal(t, fake.ErrFakeConnReset, err)
+	_, err = c1.Write(b)
+	assert.Equal(t, fake.ErrFakeConnReset, err)
+}
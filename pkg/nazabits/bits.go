@@ -8,7 +8,10 @@
 
 package nazabits
 
-import "errors"
+import (
+	"errors"
+	"math/bits"
+)
 
 var ErrNazaBits = errors.New("nazabits: fxxk")
 
@@ -281,54 +284,199 @@ func (br *BitReader) reserve(n uint) error {
 
 // ----------------------------------------------------------------------------
 
-// TODO chef: BitWriter没有对写越界做检查，由调用方保证这一点，后续可能会加上检查
-
+// BitWriter 按位流式写入字节切片
+// 从高位向低位写
+// 写越界时，如果是通过 NewGrowableBitWriter 创建的，则自动扩容，否则记录错误，之后的写入都不生效
+// 注意，可以在多次写入后，通过 Err 判断是否发生错误
 type BitWriter struct {
-	core  []byte
-	index int
-	pos   uint // 从左往右
+	core     []byte
+	index    int  // 从0开始，待写入的字节下标
+	pos      uint // 从左往右，从高位往低位 [0, 7]
+	growable bool
+	err      error
 }
 
+// NewBitWriter 写入`b`中，写入时覆盖`b`中原有的位，写越界时不扩容
 func NewBitWriter(b []byte) BitWriter {
 	return BitWriter{
 		core: b,
 	}
 }
 
+// NewGrowableBitWriter 写入内部的缓冲中，写越界时自动扩容
+//
+// @param capacity: 内部缓冲的初始容量，单位字节
+func NewGrowableBitWriter(capacity int) BitWriter {
+	return BitWriter{
+		core:     make([]byte, 0, capacity),
+		growable: true,
+	}
+}
+
 // @param b: 当b不为0和1时，取b的最低位
 func (bw *BitWriter) WriteBit(b uint8) {
-	if b&0x1 == 1 {
-		bw.core[bw.index] |= 1 << (7 - bw.pos)
-	} else {
-		bw.core[bw.index] &= ^(1 << (7 - bw.pos))
-	}
-	bw.pos++
-	if bw.pos == 8 {
-		bw.pos = 0
-		bw.index++
-	}
+	bw.writeBits(1, uint64(b))
 }
 
 // 将<v>的低<n>位写入
 // @param n: 取值范围 [1, 8]
 func (bw *BitWriter) WriteBits8(n uint, v uint8) {
-	for i := n - 1; ; i-- {
-		bw.WriteBit(v >> i & 0x1)
-		if i == 0 {
-			break
+	bw.writeBits(n, uint64(v))
+}
+
+// @param n: 取值范围 [1, 16]
+func (bw *BitWriter) WriteBits16(n uint, v uint16) {
+	bw.writeBits(n, uint64(v))
+}
+
+// @param n: 取值范围 [1, 32]
+func (bw *BitWriter) WriteBits32(n uint, v uint32) {
+	bw.writeBits(n, uint64(v))
+}
+
+// @param n: 取值范围 [1, 64]
+func (bw *BitWriter) WriteBits64(n uint, v uint64) {
+	bw.writeBits(n, v)
+}
+
+func (bw *BitWriter) WriteBytes(b []byte) {
+	// 对常见的pos为0的情况单独做优化
+	if bw.pos == 0 && bw.err == nil {
+		if bw.growable {
+			bw.core = append(bw.core[:bw.index], b...)
+			bw.index += len(b)
+			return
+		}
+		if bw.index+len(b) <= len(bw.core) {
+			copy(bw.core[bw.index:], b)
+			bw.index += len(b)
+			return
 		}
 	}
+
+	for _, v := range b {
+		bw.writeBits(8, uint64(v))
+	}
 }
 
-func (bw *BitWriter) WriteBits16(n uint, v uint16) {
-	for i := n - 1; ; i-- {
-		bw.WriteBit(uint8(v >> i & 0x1))
-		if i == 0 {
-			break
+// WriteUeGolomb 0阶指数哥伦布编码，无符号，见 BitReader.ReadUeGolomb
+func (bw *BitWriter) WriteUeGolomb(v uint32) {
+	bw.writeUeGolomb(uint64(v))
+}
+
+// WriteSeGolomb 哥伦布编码，有符号，见 BitReader.ReadSeGolomb
+func (bw *BitWriter) WriteSeGolomb(v int32) {
+	// 正数k映射为2k-1，非正数k映射为-2k
+	if v > 0 {
+		bw.writeUeGolomb(uint64(v)*2 - 1)
+	} else {
+		bw.writeUeGolomb(uint64(-int64(v)) * 2)
+	}
+}
+
+// Align 如果没有字节对齐，则使用<stuffing>填充当前字节剩余的位
+//
+// @param stuffing: 填充的位，取值范围 [0, 1]，当不为0和1时，取最低位
+func (bw *BitWriter) Align(stuffing uint8) {
+	if bw.pos == 0 {
+		return
+	}
+	n := 8 - bw.pos
+	if stuffing&0x1 == 1 {
+		bw.writeBits(n, uint64(m1[n]))
+	} else {
+		bw.writeBits(n, 0)
+	}
+}
+
+// WriteRbspTrailingBits 写入H.264/H.265的rbsp_trailing_bits，也即先写入一个1，再用0填充至字节对齐
+func (bw *BitWriter) WriteRbspTrailingBits() {
+	bw.WriteBit(1)
+	bw.Align(0)
+}
+
+// IsAligned 当前是否字节对齐
+func (bw *BitWriter) IsAligned() bool {
+	return bw.pos == 0
+}
+
+// Bytes 返回已写入的内容，如果最后一个字节没有写满，也包含在内
+//
+// 注意，返回值和内部缓冲共用内存
+func (bw *BitWriter) Bytes() []byte {
+	n := bw.index
+	if bw.pos != 0 {
+		n++
+	}
+	return bw.core[:n]
+}
+
+// BitLen 返回已写入的bit数量
+func (bw *BitWriter) BitLen() uint {
+	return uint(bw.index)*8 + bw.pos
+}
+
+func (bw *BitWriter) Err() error {
+	return bw.err
+}
+
+func (bw *BitWriter) writeUeGolomb(v uint64) {
+	// 先写入n个0，再以n+1位写入v+1
+	// 注意，调用方保证v不超过1<<32，所以v+1不会溢出，n+1也不会超过64
+	v++
+	n := uint(bits.Len64(v)) - 1
+	if n > 0 {
+		bw.writeBits(n, 0)
+	}
+	bw.writeBits(n+1, v)
+}
+
+// 将<v>的低<n>位写入
+func (bw *BitWriter) writeBits(n uint, v uint64) {
+	if bw.err != nil {
+		return
+	}
+	if n == 0 || n > 64 {
+		bw.err = ErrNazaBits
+		return
+	}
+
+	for n > 0 {
+		if bw.pos == 0 && !bw.reserveByte() {
+			return
+		}
+
+		// 当前字节还可以写入k位
+		k := 8 - bw.pos
+		if k > n {
+			k = n
+		}
+		shift := 8 - bw.pos - k
+		b := uint8(v>>(n-k)) & m1[k]
+		bw.core[bw.index] = bw.core[bw.index]&^(m1[k]<<shift) | b<<shift
+
+		n -= k
+		bw.pos += k
+		if bw.pos == 8 {
+			bw.pos = 0
+			bw.index++
 		}
 	}
 }
 
+// 确保<index>对应的字节可写
+func (bw *BitWriter) reserveByte() bool {
+	if bw.index < len(bw.core) {
+		return true
+	}
+	if bw.growable {
+		bw.core = append(bw.core, 0)
+		return true
+	}
+	bw.err = ErrNazaBits
+	return false
+}
+
 // ----------------------------------------------------------------------------
 
 // TODO chef: func GetBitX和func GetBitsX没有对写越界做检查，由调用方保证这一点，后续可能会加上检查
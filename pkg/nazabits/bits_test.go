@@ -435,6 +435,105 @@ func TestBitWriter_WriteBits16(t *testing.T) {
 	assert.Equal(t, uint8(0xFF), v[1])
 }
 
+func TestBitWriter_Growable(t *testing.T) {
+	bw := nazabits.NewGrowableBitWriter(0)
+	bw.WriteBit(1)
+	bw.WriteBits8(3, 5)
+	bw.WriteBits16(12, 0xABC)
+	bw.WriteBits32(32, 0xDEADBEEF)
+	bw.WriteBits64(64, 0x0123456789ABCDEF)
+	bw.WriteBytes([]byte("hi"))
+	bw.WriteBits8(5, 0x1F)
+	assert.Equal(t, nil, bw.Err())
+	assert.Equal(t, uint(1+3+12+32+64+16+5), bw.BitLen())
+	assert.Equal(t, false, bw.IsAligned())
+	assert.Equal(t, 17, len(bw.Bytes()))
+
+	br := nazabits.NewBitReader(bw.Bytes())
+	v1, _ := br.ReadBit()
+	assert.Equal(t, uint8(1), v1)
+	v8, _ := br.ReadBits8(3)
+	assert.Equal(t, uint8(5), v8)
+	v16, _ := br.ReadBits16(12)
+	assert.Equal(t, uint16(0xABC), v16)
+	assert.Equal(t, uint32(0xDEADBEEF), br.ReadBits32IgnErr(32))
+	v64, _ := br.ReadBits64(64)
+	assert.Equal(t, uint64(0x0123456789ABCDEF), v64)
+	assert.Equal(t, "hi", br.ReadStringIgnErr(2))
+	v8, _ = br.ReadBits8(5)
+	assert.Equal(t, uint8(0x1F), v8)
+	assert.Equal(t, nil, br.Err())
+
+	// 对齐后写入字节切片
+	bw = nazabits.NewGrowableBitWriter(4)
+	bw.WriteBits8(4, 0xF)
+	bw.Align(1)
+	assert.Equal(t, true, bw.IsAligned())
+	bw.WriteBytes([]byte{1, 2})
+	bw.WriteBits8(2, 1)
+	bw.WriteRbspTrailingBits()
+	assert.Equal(t, []byte{0xFF, 1, 2, 0x60}, bw.Bytes())
+	assert.Equal(t, uint(32), bw.BitLen())
+}
+
+func TestBitWriter_Golomb(t *testing.T) {
+	// 和 TestBitReader_ReadGolomb 中的用例对应
+	bw := nazabits.NewGrowableBitWriter(0)
+	bw.WriteUeGolomb(720)
+	bw.Align(0)
+	assert.Equal(t, []byte{0x0, 0x5a, 0x20}, bw.Bytes())
+
+	bw = nazabits.NewGrowableBitWriter(0)
+	bw.WriteSeGolomb(0)
+	bw.WriteSeGolomb(-3)
+	bw.Align(0)
+	// 1 00111 00
+	assert.Equal(t, []byte{0x9c}, bw.Bytes())
+
+	ues := []uint32{0, 1, 2, 3, 7, 15, 255, 256, 720, 65535, 1<<31 - 1, 1 << 31, 1<<32 - 1}
+	ses := []int32{0, 1, -1, 2, -2, 360, -360, 1 << 20, -1 << 20}
+	bw = nazabits.NewGrowableBitWriter(0)
+	for _, v := range ues {
+		bw.WriteUeGolomb(v)
+	}
+	for _, v := range ses {
+		bw.WriteSeGolomb(v)
+	}
+	assert.Equal(t, nil, bw.Err())
+
+	br := nazabits.NewBitReader(bw.Bytes())
+	for _, v := range ues {
+		uv, err := br.ReadUeGolomb()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, v, uv)
+	}
+	for _, v := range ses {
+		sv, err := br.ReadSeGolomb()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, v, sv)
+	}
+}
+
+func TestBitWriter_Overflow(t *testing.T) {
+	v := make([]byte, 2)
+	bw := nazabits.NewBitWriter(v)
+	bw.WriteBits8(4, 0xF)
+	bw.WriteBits16(16, 0xFFFF)
+	assert.Equal(t, nazabits.ErrNazaBits, bw.Err())
+	// 出错后的写入都不生效
+	bw.WriteBit(1)
+	assert.Equal(t, nazabits.ErrNazaBits, bw.Err())
+
+	bw = nazabits.NewBitWriter(make([]byte, 2))
+	bw.WriteBytes([]byte{1, 2, 3})
+	assert.Equal(t, nazabits.ErrNazaBits, bw.Err())
+
+	bw = nazabits.NewBitWriter(make([]byte, 16))
+	bw.WriteBits64(65, 0)
+	assert.Equal(t, nazabits.ErrNazaBits, bw.Err())
+	assert.Equal(t, uint(0), bw.BitLen())
+}
+
 func TestBitReader_AvailBits(t *testing.T) {
 	v := []byte{1}
 	br := nazabits.NewBitReader(v)
@@ -586,3 +685,19 @@ func BenchmarkBitReader_ReadBytes(b *testing.B) {
 	}
 	_ = ret
 }
+
+func BenchmarkBitWriter_WriteBits32(b *testing.B) {
+	v := make([]byte, 4)
+	for i := 0; i < b.N; i++ {
+		bw := nazabits.NewBitWriter(v)
+		bw.WriteBits32(32, 0x30393039)
+	}
+}
+
+func BenchmarkBitWriter_WriteUeGolomb(b *testing.B) {
+	v := make([]byte, 8)
+	for i := 0; i < b.N; i++ {
+		bw := nazabits.NewBitWriter(v)
+		bw.WriteUeGolomb(720)
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazabits
+
+// H.264/H.265的NAL unit中，为了避免负载中出现起始码，在连续的两个0x00后面，如果下一个字节小于等于0x03，则插入一个0x03，
+// 也即防竞争字节（emulation_prevention_three_byte）。
+// 插入防竞争字节前的数据称为RBSP，插入后的数据称为EBSP。
+
+// EbspToRbsp 去除`ebsp`中的防竞争字节
+//
+// @return 新申请的内存块
+func EbspToRbsp(ebsp []byte) []byte {
+	rbsp := make([]byte, 0, len(ebsp))
+	zeros := 0
+	for _, b := range ebsp {
+		if zeros >= 2 && b == 0x03 {
+			zeros = 0
+			continue
+		}
+		if b == 0x00 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		rbsp = append(rbsp, b)
+	}
+	return rbsp
+}
+
+// RbspToEbsp 在`rbsp`中插入防竞争字节
+//
+// 如果`rbsp`以0x00结尾，则在尾部追加一个0x03
+//
+// @return 新申请的内存块
+func RbspToEbsp(rbsp []byte) []byte {
+	ebsp := make([]byte, 0, len(rbsp)+len(rbsp)/64+1)
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros >= 2 && b <= 0x03 {
+			ebsp = append(ebsp, 0x03)
+			zeros = 0
+		}
+		if b == 0x00 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		ebsp = append(ebsp, b)
+	}
+	if len(rbsp) != 0 && rbsp[len(rbsp)-1] == 0x00 {
+		ebsp = append(ebsp, 0x03)
+	}
+	return ebsp
+}
+
+// ----------------------------------------------------------------------------
+
+// RbspReader 从EBSP中按位读取RBSP，读取时自动跳过防竞争字节，不需要先拷贝一份去除防竞争字节后的数据
+// 从高位向低位读
+// 注意，和 BitReader 一样，可以在每次读取后，判断是否发生错误。也可以在多次读取后，判断是否发生错误。
+type RbspReader struct {
+	ebsp  []byte
+	index int // 从0开始，下一个待获取的字节在 ebsp 中的下标
+	zeros int // 已获取的连续0x00的个数，用于识别防竞争字节
+
+	curr     uint8 // 当前正在读取的字节
+	currBits uint  // curr 中还没有读取的bit数量
+	err      error
+
+	stopIndex int  // rbsp_stop_one_bit 所在字节在 ebsp 中的下标，-1表示没有
+	stopPos   uint // rbsp_stop_one_bit 在字节中的位置，从左往右，从高位往低位 [0, 7]
+}
+
+// NewRbspReader
+//
+// @param ebsp: 不包含起始码，可以包含NAL头
+func NewRbspReader(ebsp []byte) RbspReader {
+	r := RbspReader{
+		ebsp:      ebsp,
+		stopIndex: -1,
+	}
+	r.findStopBit()
+	return r
+}
+
+func (r *RbspReader) ReadBit() (uint8, error) {
+	v, err := r.readBits(1)
+	return uint8(v), err
+}
+
+// @param n: 取值范围 [1, 8]
+func (r *RbspReader) ReadBits8(n uint) (uint8, error) {
+	v, err := r.readBits(n)
+	return uint8(v), err
+}
+
+// @param n: 取值范围 [1, 16]
+func (r *RbspReader) ReadBits16(n uint) (uint16, error) {
+	v, err := r.readBits(n)
+	return uint16(v), err
+}
+
+// @param n: 取值范围 [1, 32]
+func (r *RbspReader) ReadBits32(n uint) (uint32, error) {
+	v, err := r.readBits(n)
+	return uint32(v), err
+}
+
+// @param n: 取值范围 [1, 64]
+func (r *RbspReader) ReadBits64(n uint) (uint64, error) {
+	return r.readBits(n)
+}
+
+// ReadBytes
+// @param n: 读取多少个字节，不包含防竞争字节
+func (r *RbspReader) ReadBytes(n uint) (ret []byte, err error) {
+	ret = make([]byte, 0, n)
+	for i := uint(0); i < n; i++ {
+		var v uint64
+		if v, err = r.readBits(8); err != nil {
+			return nil, err
+		}
+		ret = append(ret, uint8(v))
+	}
+	return
+}
+
+// ReadUeGolomb 0阶指数哥伦布编码，无符号
+func (r *RbspReader) ReadUeGolomb() (uint32, error) {
+	return readUeGolomb(r)
+}
+
+// ReadSeGolomb 哥伦布编码，有符号
+func (r *RbspReader) ReadSeGolomb() (int32, error) {
+	return readSeGolomb(r)
+}
+
+func (r *RbspReader) ReadBits32IgnErr(n uint) uint32 {
+	v, _ := r.ReadBits32(n)
+	return v
+}
+
+// SkipBits
+// @param n: 跳过多少位，不包含防竞争字节
+func (r *RbspReader) SkipBits(n uint) error {
+	for n > 0 {
+		k := n
+		if k > 64 {
+			k = 64
+		}
+		if _, err := r.readBits(k); err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
+
+func (r *RbspReader) SkipBytes(n uint) error {
+	return r.SkipBits(n * 8)
+}
+
+// IsAligned 当前是否字节对齐
+func (r *RbspReader) IsAligned() bool {
+	return r.currBits == 0
+}
+
+// MoreRbspData 对应H.264/H.265标准中的more_rbsp_data()，当前位置到rbsp_trailing_bits之间是否还有数据
+//
+// rbsp_trailing_bits 为最后一个值为1的位（rbsp_stop_one_bit），以及它后面的0，尾部的cabac_zero_word也会被忽略
+func (r *RbspReader) MoreRbspData() bool {
+	if r.err != nil || r.stopIndex < 0 {
+		return false
+	}
+	index, pos := r.index, uint(0)
+	if r.currBits != 0 {
+		index, pos = r.index-1, 8-r.currBits
+	}
+	return index < r.stopIndex || (index == r.stopIndex && pos < r.stopPos)
+}
+
+func (r *RbspReader) Err() error {
+	return r.err
+}
+
+func (r *RbspReader) fail() error {
+	r.err = ErrNazaBits
+	return r.err
+}
+
+func (r *RbspReader) readBits(n uint) (v uint64, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if n > 64 {
+		return 0, r.fail()
+	}
+
+	for n > 0 {
+		if r.currBits == 0 && !r.nextByte() {
+			return 0, r.fail()
+		}
+		k := r.currBits
+		if k > n {
+			k = n
+		}
+		v = v<<k | uint64(r.curr>>(r.currBits-k)&m1[k])
+		r.currBits -= k
+		n -= k
+	}
+	return v, nil
+}
+
+// nextByte 获取下一个字节，跳过防竞争字节
+func (r *RbspReader) nextByte() bool {
+	for r.index < len(r.ebsp) {
+		b := r.ebsp[r.index]
+		r.index++
+		if r.zeros >= 2 && b == 0x03 {
+			r.zeros = 0
+			continue
+		}
+		if b == 0x00 {
+			r.zeros++
+		} else {
+			r.zeros = 0
+		}
+		r.curr = b
+		r.currBits = 8
+		return true
+	}
+	return false
+}
+
+// findStopBit 从尾部开始，跳过值为0x00的字节和防竞争字节，找到rbsp_stop_one_bit
+func (r *RbspReader) findStopBit() {
+	for i := len(r.ebsp) - 1; i >= 0; i-- {
+		b := r.ebsp[i]
+		if b == 0x00 {
+			continue
+		}
+		if b == 0x03 && i >= 2 && r.ebsp[i-1] == 0x00 && r.ebsp[i-2] == 0x00 {
+			continue
+		}
+		r.stopIndex = i
+		r.stopPos = 7
+		for b&0x1 == 0 {
+			b >>= 1
+			r.stopPos--
+		}
+		return
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// golombReader 各种按位读取的Reader共用的指数哥伦布解码逻辑
+type golombReader interface {
+	ReadBit() (uint8, error)
+	ReadBits32(n uint) (uint32, error)
+	fail() error // 记录错误，之后的读取都返回该错误
+}
+
+func readUeGolomb(r golombReader) (v uint32, err error) {
+	var t uint8
+	var n uint
+	for {
+		if t, err = r.ReadBit(); err != nil {
+			return
+		}
+		if t == 1 {
+			break
+		}
+		n++
+		if n > 32 {
+			return 0, r.fail()
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	var m uint32
+	if m, err = r.ReadBits32(n); err != nil {
+		return
+	}
+	v = 1<<n + m - 1
+	return
+}
+
+func readSeGolomb(r golombReader) (v int32, err error) {
+	var vv uint32
+	if vv, err = readUeGolomb(r); err != nil {
+		return 0, err
+	}
+	// 奇数映射为正数，偶数映射为非正数
+	if vv&1 == 1 {
+		return int32(vv/2 + 1), nil
+	}
+	return -int32(vv / 2), nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazabits_test
+
+import (
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/nazabits"
+)
+
+func TestEbspToRbsp(t *testing.T) {
+	cases := []struct {
+		rbsp []byte
+		ebsp []byte
+	}{
+		{[]byte{}, []byte{}},
+		{[]byte{0, 0, 1}, []byte{0, 0, 3, 1}},
+		{[]byte{0, 0, 3}, []byte{0, 0, 3, 3}},
+		{[]byte{0, 0, 4}, []byte{0, 0, 4}},
+		{[]byte{0, 0, 0, 0, 0, 1}, []byte{0, 0, 3, 0, 0, 3, 0, 1}},
+		{[]byte{1, 0, 0}, []byte{1, 0, 0, 3}},
+		{[]byte{0x67, 0, 0, 2, 0xFF}, []byte{0x67, 0, 0, 3, 2, 0xFF}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.ebsp, nazabits.RbspToEbsp(c.rbsp))
+		assert.Equal(t, c.rbsp, nazabits.EbspToRbsp(c.ebsp))
+	}
+}
+
+func TestRbspReader(t *testing.T) {
+	// 构造一个会产生防竞争字节的RBSP
+	bw := nazabits.NewGrowableBitWriter(0)
+	bw.WriteBits8(8, 0x67)
+	bw.WriteBytes([]byte{0, 0, 1})
+	bw.WriteUeGolomb(0xFFFF)
+	bw.WriteBits32(32, 0)
+	bw.WriteSeGolomb(-300)
+	bw.WriteBits16(13, 3)
+	bw.WriteRbspTrailingBits()
+	rbsp := bw.Bytes()
+	ebsp := nazabits.RbspToEbsp(rbsp)
+	// 尾部的cabac_zero_word
+	ebsp = append(ebsp, 0, 0, 3, 0, 0, 3)
+	assert.Equal(t, true, len(ebsp) > len(rbsp)+6)
+
+	r := nazabits.NewRbspReader(ebsp)
+	v8, err := r.ReadBits8(8)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint8(0x67), v8)
+	b, err := r.ReadBytes(3)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []byte{0, 0, 1}, b)
+	ue, err := r.ReadUeGolomb()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint32(0xFFFF), ue)
+	assert.Equal(t, nil, r.SkipBits(32))
+	se, err := r.ReadSeGolomb()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int32(-300), se)
+	assert.Equal(t, true, r.MoreRbspData())
+	v16, err := r.ReadBits16(13)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint16(3), v16)
+	assert.Equal(t, false, r.MoreRbspData())
+
+	// 读取rbsp_trailing_bits后再读取cabac_zero_word
+	stop, _ := r.ReadBit()
+	assert.Equal(t, uint8(1), stop)
+	for !r.IsAligned() {
+		_, _ = r.ReadBit()
+	}
+	v32, err := r.ReadBits32(32)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint32(0), v32)
+	_, err = r.ReadBit()
+	assert.Equal(t, nazabits.ErrNazaBits, err)
+	assert.Equal(t, nazabits.ErrNazaBits, r.Err())
+	assert.Equal(t, false, r.MoreRbspData())
+}
+
+func TestRbspReader_MoreRbspData(t *testing.T) {
+	// 1 1000000
+	r := nazabits.NewRbspReader([]byte{0xC0})
+	assert.Equal(t, true, r.MoreRbspData())
+	_, _ = r.ReadBit()
+	assert.Equal(t, false, r.MoreRbspData())
+
+	// 停止位在下一个字节的最高位
+	r = nazabits.NewRbspReader([]byte{0xFF, 0x80})
+	_ = r.SkipBits(7)
+	assert.Equal(t, true, r.MoreRbspData())
+	_ = r.SkipBits(1)
+	assert.Equal(t, false, r.MoreRbspData())
+
+	// 没有停止位
+	r = nazabits.NewRbspReader([]byte{0, 0, 3})
+	assert.Equal(t, false, r.MoreRbspData())
+
+	r = nazabits.NewRbspReader([]byte{0x80})
+	_, err := r.ReadBits64(65)
+	assert.Equal(t, nazabits.ErrNazaBits, err)
+}
+
+func BenchmarkRbspReader_ReadBits32(b *testing.B) {
+	v := []byte{0, 0, 3, 1, 48, 57}
+	var ret uint32
+	for i := 0; i < b.N; i++ {
+		r := nazabits.NewRbspReader(v)
+		ret, _ = r.ReadBits32(32)
+	}
+	_ = ret
+}
@@ -0,0 +1,258 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazabits
+
+import "io"
+
+const defaultStreamBufSize = 4096
+
+// StreamBitReader 从 io.Reader 中按位流式读取，适用于数据量大或者从网络中陆续到达的场景，比如TS包，FLV tag
+// 从高位向低位读
+// 接口和 BitReader 相同，同样可以在多次读取后，通过 Err 判断是否发生错误
+//
+// 注意，内部有缓冲，会从 io.Reader 中多读取一些数据
+type StreamBitReader struct {
+	r     io.Reader
+	buf   []byte
+	start int // buf[start:end] 为已经从 io.Reader 中读取但还没有使用的数据
+	end   int
+
+	curr     uint8 // 当前正在读取的字节
+	currBits uint  // curr 中还没有读取的bit数量
+	nbytes   int64 // 已经从 buf 中取出的字节数，包括 curr
+	err      error
+}
+
+// NewStreamBitReader
+//
+// @param bufSize: 内部缓冲的大小，单位字节，为0则使用默认值4096
+func NewStreamBitReader(r io.Reader, bufSize int) *StreamBitReader {
+	if bufSize <= 0 {
+		bufSize = defaultStreamBufSize
+	}
+	return &StreamBitReader{
+		r:   r,
+		buf: make([]byte, bufSize),
+	}
+}
+
+func (sr *StreamBitReader) ReadBit() (uint8, error) {
+	v, err := sr.readBits(1)
+	return uint8(v), err
+}
+
+// @param n: 取值范围 [1, 8]
+func (sr *StreamBitReader) ReadBits8(n uint) (uint8, error) {
+	v, err := sr.readBits(n)
+	return uint8(v), err
+}
+
+// @param n: 取值范围 [1, 16]
+func (sr *StreamBitReader) ReadBits16(n uint) (uint16, error) {
+	v, err := sr.readBits(n)
+	return uint16(v), err
+}
+
+// @param n: 取值范围 [1, 32]
+func (sr *StreamBitReader) ReadBits32(n uint) (uint32, error) {
+	v, err := sr.readBits(n)
+	return uint32(v), err
+}
+
+// @param n: 取值范围 [1, 64]
+func (sr *StreamBitReader) ReadBits64(n uint) (uint64, error) {
+	return sr.readBits(n)
+}
+
+// ReadBytes
+// @param n: 读取多少个字节
+func (sr *StreamBitReader) ReadBytes(n uint) (r []byte, err error) {
+	r = make([]byte, n)
+	// 对常见的字节对齐的情况单独做优化
+	if sr.currBits == 0 {
+		if err = sr.readAligned(r); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	for i := range r {
+		var v uint64
+		if v, err = sr.readBits(8); err != nil {
+			return nil, err
+		}
+		r[i] = uint8(v)
+	}
+	return r, nil
+}
+
+func (sr *StreamBitReader) ReadString(n uint) (r string, err error) {
+	var bs []byte
+	if bs, err = sr.ReadBytes(n); err != nil {
+		return
+	}
+	return string(bs), nil
+}
+
+func (sr *StreamBitReader) ReadGolomb() (uint32, error) {
+	return sr.ReadUeGolomb()
+}
+
+// ReadUeGolomb 0阶指数哥伦布编码，无符号
+func (sr *StreamBitReader) ReadUeGolomb() (uint32, error) {
+	return readUeGolomb(sr)
+}
+
+// ReadSeGolomb 哥伦布编码，有符号
+func (sr *StreamBitReader) ReadSeGolomb() (int32, error) {
+	return readSeGolomb(sr)
+}
+
+func (sr *StreamBitReader) ReadBits32IgnErr(n uint) uint32 {
+	r, _ := sr.ReadBits32(n)
+	return r
+}
+
+func (sr *StreamBitReader) ReadStringIgnErr(n uint) string {
+	r, _ := sr.ReadString(n)
+	return r
+}
+
+func (sr *StreamBitReader) SkipBytes(n uint) error {
+	return sr.SkipBits(n * 8)
+}
+
+func (sr *StreamBitReader) SkipBits(n uint) error {
+	if sr.err != nil {
+		return sr.err
+	}
+	// 先读完当前字节，再整字节跳过缓冲中的数据
+	if sr.currBits != 0 {
+		k := sr.currBits
+		if k > n {
+			k = n
+		}
+		if _, err := sr.readBits(k); err != nil {
+			return err
+		}
+		n -= k
+	}
+	for n >= 8 {
+		if sr.start == sr.end && !sr.refill() {
+			return sr.err
+		}
+		k := sr.end - sr.start
+		if uint(k) > n/8 {
+			k = int(n / 8)
+		}
+		sr.start += k
+		sr.nbytes += int64(k)
+		n -= uint(k) * 8
+	}
+	if n > 0 {
+		_, err := sr.readBits(n)
+		return err
+	}
+	return nil
+}
+
+// IsAligned 当前是否字节对齐
+func (sr *StreamBitReader) IsAligned() bool {
+	return sr.currBits == 0
+}
+
+// BitPos 返回已读取的bit数量
+func (sr *StreamBitReader) BitPos() int64 {
+	return sr.nbytes*8 - int64(sr.currBits)
+}
+
+// Offset 返回下一个待读取的位置，含义同 BitReader 内部的下标
+//
+// @return index: 从0开始，待读取的字节下标
+// @return pos:   从左往右，从高位往低位 [0, 7]
+func (sr *StreamBitReader) Offset() (index int64, pos uint) {
+	bitPos := sr.BitPos()
+	return bitPos / 8, uint(bitPos % 8)
+}
+
+// Err 数据不够时返回 ErrNazaBits ， io.Reader 返回其他错误时返回该错误
+func (sr *StreamBitReader) Err() error {
+	return sr.err
+}
+
+func (sr *StreamBitReader) fail() error {
+	sr.err = ErrNazaBits
+	return sr.err
+}
+
+func (sr *StreamBitReader) readBits(n uint) (v uint64, err error) {
+	if sr.err != nil {
+		return 0, sr.err
+	}
+	if n > 64 {
+		return 0, sr.fail()
+	}
+
+	for n > 0 {
+		if sr.currBits == 0 && !sr.nextByte() {
+			return 0, sr.err
+		}
+		k := sr.currBits
+		if k > n {
+			k = n
+		}
+		v = v<<k | uint64(sr.curr>>(sr.currBits-k)&m1[k])
+		sr.currBits -= k
+		n -= k
+	}
+	return v, nil
+}
+
+// readAligned 字节对齐时，读取len(b)个字节
+func (sr *StreamBitReader) readAligned(b []byte) error {
+	for len(b) > 0 {
+		if sr.start == sr.end && !sr.refill() {
+			return sr.err
+		}
+		k := copy(b, sr.buf[sr.start:sr.end])
+		sr.start += k
+		sr.nbytes += int64(k)
+		b = b[k:]
+	}
+	return nil
+}
+
+func (sr *StreamBitReader) nextByte() bool {
+	if sr.start == sr.end && !sr.refill() {
+		return false
+	}
+	sr.curr = sr.buf[sr.start]
+	sr.currBits = 8
+	sr.start++
+	sr.nbytes++
+	return true
+}
+
+// refill 缓冲中的数据用完后，从 io.Reader 中读取
+func (sr *StreamBitReader) refill() bool {
+	if sr.err != nil {
+		return false
+	}
+	n, err := io.ReadAtLeast(sr.r, sr.buf, 1)
+	if n > 0 {
+		sr.start = 0
+		sr.end = n
+		return true
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrNazaBits
+	}
+	sr.err = err
+	return false
+}
@@ -0,0 +1,145 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazabits_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/nazabits"
+)
+
+func TestStreamBitReader(t *testing.T) {
+	bw := nazabits.NewGrowableBitWriter(0)
+	bw.WriteBits8(3, 5)
+	bw.WriteBits16(13, 0x1234)
+	bw.WriteUeGolomb(0xFFFF)
+	bw.WriteSeGolomb(-300)
+	bw.WriteBits64(64, 0x0123456789ABCDEF)
+	bw.WriteBits8(1, 1)
+	bw.Align(0)
+	bw.WriteBytes([]byte("hello"))
+	bw.WriteBits8(4, 0xA)
+	bw.WriteBytes([]byte("world"))
+	bw.WriteBits8(4, 0x5)
+	bw.WriteBytes(make([]byte, 100))
+	bw.WriteBits8(8, 0x7F)
+	b := bw.Bytes()
+
+	// 分别使用足够大的缓冲，很小的缓冲，以及每次只返回一个字节的io.Reader
+	readers := []func() *nazabits.StreamBitReader{
+		func() *nazabits.StreamBitReader { return nazabits.NewStreamBitReader(bytes.NewReader(b), 0) },
+		func() *nazabits.StreamBitReader { return nazabits.NewStreamBitReader(bytes.NewReader(b), 3) },
+		func() *nazabits.StreamBitReader {
+			return nazabits.NewStreamBitReader(iotest.OneByteReader(bytes.NewReader(b)), 16)
+		},
+	}
+	for _, newReader := range readers {
+		sr := newReader()
+		v8, err := sr.ReadBits8(3)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint8(5), v8)
+		assert.Equal(t, int64(3), sr.BitPos())
+		v16, err := sr.ReadBits16(13)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint16(0x1234), v16)
+		assert.Equal(t, true, sr.IsAligned())
+		ue, err := sr.ReadUeGolomb()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint32(0xFFFF), ue)
+		se, err := sr.ReadSeGolomb()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int32(-300), se)
+		v64, err := sr.ReadBits64(64)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint64(0x0123456789ABCDEF), v64)
+		bit, err := sr.ReadBit()
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint8(1), bit)
+		for !sr.IsAligned() {
+			_, _ = sr.ReadBit()
+		}
+		index, pos := sr.Offset()
+		assert.Equal(t, int64(sr.BitPos()/8), index)
+		assert.Equal(t, uint(0), pos)
+
+		assert.Equal(t, "hello", sr.ReadStringIgnErr(5))
+		assert.Equal(t, uint32(0xA), sr.ReadBits32IgnErr(4))
+		// 非字节对齐时读取字节
+		bs, err := sr.ReadBytes(5)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []byte("world"), bs)
+		index, pos = sr.Offset()
+		assert.Equal(t, uint(4), pos)
+		assert.Equal(t, nil, sr.SkipBits(4))
+		assert.Equal(t, nil, sr.SkipBytes(100))
+		assert.Equal(t, index+101, sr.BitPos()/8)
+		v8, err = sr.ReadBits8(8)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, uint8(0x7F), v8)
+		assert.Equal(t, int64(len(b)*8), sr.BitPos())
+
+		// 数据读完后，错误会一直保留
+		_, err = sr.ReadBit()
+		assert.Equal(t, nazabits.ErrNazaBits, err)
+		assert.Equal(t, nazabits.ErrNazaBits, sr.Err())
+		_, err = sr.ReadBytes(1)
+		assert.Equal(t, nazabits.ErrNazaBits, err)
+	}
+}
+
+func TestStreamBitReader_Err(t *testing.T) {
+	sr := nazabits.NewStreamBitReader(bytes.NewReader([]byte{0xFF, 0xFF}), 0)
+	_, err := sr.ReadBits64(65)
+	assert.Equal(t, nazabits.ErrNazaBits, err)
+	_, err = sr.ReadBit()
+	assert.Equal(t, nazabits.ErrNazaBits, err)
+
+	sr = nazabits.NewStreamBitReader(bytes.NewReader([]byte{0xFF, 0xFF}), 0)
+	assert.Equal(t, nazabits.ErrNazaBits, sr.SkipBits(17))
+
+	// 读取到一半时，io.Reader 返回了其他错误
+	errRead := errors.New("read error")
+	sr = nazabits.NewStreamBitReader(io.MultiReader(bytes.NewReader([]byte{0x00}), errReader{errRead}), 1)
+	_, err = sr.ReadBits16(16)
+	assert.Equal(t, errRead, err)
+	assert.Equal(t, errRead, sr.Err())
+
+	// 超过32个前导0
+	sr = nazabits.NewStreamBitReader(bytes.NewReader(make([]byte, 8)), 0)
+	_, err = sr.ReadUeGolomb()
+	assert.Equal(t, nazabits.ErrNazaBits, err)
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func BenchmarkStreamBitReader_ReadBits32(b *testing.B) {
+	v := bytes.Repeat([]byte{1, 2, 3, 4}, 1024)
+	r := bytes.NewReader(v)
+	sr := nazabits.NewStreamBitReader(r, 0)
+	var ret uint32
+	for i := 0; i < b.N; i++ {
+		if sr.Err() != nil {
+			r.Reset(v)
+			sr = nazabits.NewStreamBitReader(r, 0)
+		}
+		ret, _ = sr.ReadBits32(32)
+	}
+	_ = ret
+}
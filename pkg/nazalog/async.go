@@ -0,0 +1,168 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/q191201771/naza/pkg/circularqueue"
+	"github.com/q191201771/naza/pkg/nazaatomic"
+)
+
+// asyncEntry 缓冲区中的一条已格式化的日志
+type asyncEntry struct {
+	level Level
+	t     time.Time
+	line  formattedLine
+}
+
+// asyncWriter 异步模式下，业务协程将日志放入有界的环形缓冲区，由独立的协程批量取出并输出
+type asyncWriter struct {
+	core *core
+
+	mu       sync.Mutex
+	cond     *sync.Cond // 缓冲区、writing、closed 任意一个发生变化时广播
+	queue    *circularqueue.CircularQueue
+	writing  bool // 异步协程是否正在输出已取出的日志
+	closed   bool
+	doneChan chan struct{}
+
+	entryPool  sync.Pool
+	batch      []*asyncEntry
+	droppedNum nazaatomic.Uint64
+}
+
+func newAsyncWriter(c *core) *asyncWriter {
+	w := &asyncWriter{
+		core:     c,
+		queue:    circularqueue.New(c.option.AsyncBufferSize),
+		doneChan: make(chan struct{}),
+		batch:    make([]*asyncEntry, 0, c.option.AsyncBufferSize),
+	}
+	w.cond = sync.NewCond(&w.mu)
+	w.entryPool.New = func() interface{} {
+		return &asyncEntry{}
+	}
+	go w.runLoop()
+	return w
+}
+
+func (w *asyncWriter) acquireEntry() *asyncEntry {
+	entry := w.entryPool.Get().(*asyncEntry)
+	entry.line.reset()
+	return entry
+}
+
+func (w *asyncWriter) releaseEntry(entry *asyncEntry) {
+	w.entryPool.Put(entry)
+}
+
+// push 将日志放入缓冲区，缓冲区满时根据 Option.AsyncOverflowBehavior 阻塞或者丢弃
+//
+// @return 如果异步协程已经退出，返回false，此时`entry`的所有权依然属于调用方
+func (w *asyncWriter) push(entry *asyncEntry) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for !w.closed && w.queue.Full() {
+		if w.core.option.AsyncOverflowBehavior == AsyncOverflowDrop && entry.level < LevelFatal {
+			w.droppedNum.Increment()
+			w.releaseEntry(entry)
+			return true
+		}
+		w.cond.Wait()
+	}
+	if w.closed {
+		return false
+	}
+
+	_ = w.queue.PushBack(entry)
+	w.cond.Broadcast()
+	return true
+}
+
+// flush 阻塞直到缓冲区中的日志全部输出完毕
+func (w *asyncWriter) flush() {
+	w.mu.Lock()
+	for !w.queue.Empty() || w.writing {
+		w.cond.Wait()
+	}
+	w.mu.Unlock()
+}
+
+// close 输出缓冲区中剩余的日志，并等待异步协程退出
+func (w *asyncWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+	<-w.doneChan
+}
+
+func (w *asyncWriter) runLoop() {
+	defer close(w.doneChan)
+
+	for {
+		w.mu.Lock()
+		for w.queue.Empty() && !w.closed {
+			w.cond.Wait()
+		}
+		if w.queue.Empty() && w.closed {
+			w.mu.Unlock()
+			return
+		}
+		// 一次性取出缓冲区中的所有日志
+		for !w.queue.Empty() {
+			v, _ := w.queue.PopFront()
+			w.batch = append(w.batch, v.(*asyncEntry))
+		}
+		w.writing = true
+		w.cond.Broadcast()
+		w.mu.Unlock()
+
+		w.writeBatch()
+
+		w.mu.Lock()
+		w.writing = false
+		w.cond.Broadcast()
+		w.mu.Unlock()
+	}
+}
+
+// writeBatch 将多条日志合并后输出，减少系统调用次数
+func (w *asyncWriter) writeBatch() {
+	c := w.core
+	c.m.Lock()
+
+	for _, entry := range w.batch {
+		for _, s := range c.sinks {
+			if entry.level >= s.level {
+				s.appendBatch(entry.level, entry.t, &entry.line)
+			}
+		}
+	}
+	for _, s := range c.sinks {
+		s.flushBatch()
+	}
+
+	if c.option.HookBackendOutFn != nil {
+		for _, entry := range w.batch {
+			c.option.HookBackendOutFn(entry.level, entry.line.main.Bytes())
+		}
+	}
+
+	c.m.Unlock()
+
+	for i, entry := range w.batch {
+		w.releaseEntry(entry)
+		w.batch[i] = nil
+	}
+	w.batch = w.batch[:0]
+}
@@ -0,0 +1,227 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/fake"
+	"github.com/q191201771/naza/pkg/mock"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func TestAsync(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.IsToStdout = false
+		option.IsAsync = true
+		option.AsyncBufferSize = 16
+		option.TimestampFlag = false
+		option.ShortFileFlag = false
+		option.LevelFlag = false
+		option.HookBackendOutFn = func(level nazalog.Level, line []byte) {
+			mu.Lock()
+			lines = append(lines, string(line))
+			mu.Unlock()
+		}
+	})
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 100; i++ {
+		l.Infof("%d", i)
+	}
+	l.Sync()
+	mu.Lock()
+	assert.Equal(t, 100, len(lines))
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, fmt.Sprintf("%d\n", i), lines[i])
+	}
+	mu.Unlock()
+	assert.Equal(t, uint64(0), l.GetDroppedNum())
+
+	// 切换回同步模式前，缓冲区中的日志会先输出
+	l.Info("last async")
+	err = l.Init(func(option *nazalog.Option) {
+		option.IsToStdout = false
+	})
+	assert.Equal(t, nil, err)
+	mu.Lock()
+	assert.Equal(t, "last async\n", lines[len(lines)-1])
+	mu.Unlock()
+
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.IsAsync = true
+		option.AsyncBufferSize = 0
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.IsAsync = true
+		option.AsyncOverflowBehavior = nazalog.AsyncOverflowDrop + 1
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+}
+
+func TestAsync_Overflow(t *testing.T) {
+	for _, behavior := range []nazalog.AsyncOverflowBehavior{nazalog.AsyncOverflowBlock, nazalog.AsyncOverflowDrop} {
+		enterChan := make(chan struct{}, 16)
+		releaseChan := make(chan struct{})
+		var mu sync.Mutex
+		var lines []string
+		l, err := nazalog.New(func(option *nazalog.Option) {
+			option.IsToStdout = false
+			option.IsAsync = true
+			option.AsyncBufferSize = 1
+			option.AsyncOverflowBehavior = behavior
+			option.TimestampFlag = false
+			option.ShortFileFlag = false
+			option.LevelFlag = false
+			option.HookBackendOutFn = func(level nazalog.Level, line []byte) {
+				enterChan <- struct{}{}
+				<-releaseChan
+				mu.Lock()
+				lines = append(lines, string(line))
+				mu.Unlock()
+			}
+		})
+		assert.Equal(t, nil, err)
+
+		// 异步协程阻塞在第一条日志的hook中，第二条日志占满缓冲区
+		l.Info("1")
+		<-enterChan
+		l.Info("2")
+
+		doneChan := make(chan struct{})
+		go func() {
+			l.Info("3")
+			close(doneChan)
+		}()
+
+		if behavior == nazalog.AsyncOverflowDrop {
+			<-doneChan
+			assert.Equal(t, uint64(1), l.GetDroppedNum())
+			close(releaseChan)
+			l.Sync()
+			assert.Equal(t, []string{"1\n", "2\n"}, lines)
+			continue
+		}
+
+		select {
+		case <-doneChan:
+			t.Fatal("should block")
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(releaseChan)
+		<-doneChan
+		l.Sync()
+		assert.Equal(t, []string{"1\n", "2\n", "3\n"}, lines)
+		assert.Equal(t, uint64(0), l.GetDroppedNum())
+	}
+}
+
+func TestAsync_Fatal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nazalogtest")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "async.log")
+
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Filename = filename
+		option.IsToStdout = false
+		option.IsAsync = true
+	})
+	assert.Equal(t, nil, err)
+
+	for i := 0; i < 100; i++ {
+		l.Infof("async %d", i)
+	}
+	er := fake.WithFakeOsExit(func() {
+		l.Fatal("bye")
+	})
+	assert.Equal(t, true, er.HasExit)
+
+	// Fatal返回前，所有日志都已经写入文件
+	b, err := ioutil.ReadFile(filename)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 101, strings.Count(string(b), "\n"))
+	assert.Equal(t, true, strings.Contains(string(b), "bye"))
+
+	fake.WithRecover(func() {
+		l.Panic("panic")
+	})
+	b, err = ioutil.ReadFile(filename)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.Contains(string(b), "panic"))
+}
+
+func TestAsync_Rotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nazalogtest")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "rotate.log")
+
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Filename = filename
+		option.IsToStdout = false
+		option.IsRotateHourly = true
+		option.IsAsync = true
+	})
+	assert.Equal(t, nil, err)
+
+	now := time.Now()
+	nazalog.Clock = mock.NewFakeClock()
+	defer func() {
+		nazalog.Clock = mock.NewStdClock()
+	}()
+	nazalog.Clock.Set(now)
+	l.Info("before")
+	nazalog.Clock.Set(now.Add(time.Hour))
+	l.Info("after")
+	l.Sync()
+
+	backup, err := ioutil.ReadFile(filename + "." + now.Format("2006010215"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.Contains(string(backup), "before"))
+	curr, err := ioutil.ReadFile(filename)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.Contains(string(curr), "after"))
+	assert.Equal(t, false, strings.Contains(string(curr), "before"))
+}
+
+func TestAsyncOverflowBehavior_ReadableString(t *testing.T) {
+	assert.Equal(t, "AsyncOverflowBlock", nazalog.AsyncOverflowBlock.ReadableString())
+	assert.Equal(t, "AsyncOverflowDrop", nazalog.AsyncOverflowDrop.ReadableString())
+	assert.Equal(t, "unknown", nazalog.AsyncOverflowBehavior(100).ReadableString())
+}
+
+func BenchmarkNazaLog_Async(b *testing.B) {
+	b.ReportAllocs()
+
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Level = nazalog.LevelInfo
+		option.Filename = "/dev/null"
+		option.IsToStdout = false
+		option.IsAsync = true
+	})
+	assert.Equal(b, nil, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Infof("hello %s %d", "world", i)
+		l.Info("Info")
+	}
+	l.Sync()
+}
@@ -0,0 +1,111 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"context"
+
+	"github.com/q191201771/naza/pkg/unique"
+)
+
+const (
+	TraceIdKey   = "trace_id"   // ContextWithTraceId 添加的键
+	SessionIdKey = "session_id" // ContextWithSessionId 添加的键
+
+	traceIdPrefix   = "TRACE"
+	sessionIdPrefix = "SESSION"
+)
+
+type ctxKey int
+
+const (
+	ctxKeyLogger ctxKey = iota + 1
+	ctxKeyFields
+)
+
+// NewContext 将`l`保存在返回的context中，之后可以通过 FromContext 获取
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, ctxKeyLogger, l)
+}
+
+// FromContext 获取通过 NewContext 保存的Logger对象，如果没有，则返回全局Logger对象
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKeyLogger).(Logger); ok {
+			return l
+		}
+	}
+	return global
+}
+
+// ContextWithFields 在context中添加键值对，Tracec ... 等函数打印日志时会带上这些键值对，规则同 Logger.Infow
+//
+// 之前已经添加的键值对会保留，子context添加的键值对不影响父context
+func ContextWithFields(ctx context.Context, kv ...interface{}) context.Context {
+	old := FieldsFromContext(ctx)
+	fields := make([]interface{}, 0, len(old)+len(kv))
+	fields = append(fields, old...)
+	fields = append(fields, kv...)
+	return context.WithValue(ctx, ctxKeyFields, fields)
+}
+
+// FieldsFromContext 获取通过 ContextWithFields 添加的所有键值对
+//
+// 注意，返回值只读
+func FieldsFromContext(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(ctxKeyFields).([]interface{})
+	return fields
+}
+
+// ContextWithTraceId 在context中添加键为 TraceIdKey 的键值对
+//
+// @param traceId 为空时，使用 unique 生成一个，比如"TRACE1"
+//
+// @return traceId 实际使用的trace id
+func ContextWithTraceId(ctx context.Context, traceId string) (context.Context, string) {
+	if traceId == "" {
+		traceId = unique.GenUniqueKey(traceIdPrefix)
+	}
+	return ContextWithFields(ctx, TraceIdKey, traceId), traceId
+}
+
+// ContextWithSessionId 在context中添加键为 SessionIdKey 的键值对
+//
+// @param sessionId 为空时，使用 unique 生成一个，比如"SESSION1"
+//
+// @return sessionId 实际使用的session id
+func ContextWithSessionId(ctx context.Context, sessionId string) (context.Context, string) {
+	if sessionId == "" {
+		sessionId = unique.GenUniqueKey(sessionIdPrefix)
+	}
+	return ContextWithFields(ctx, SessionIdKey, sessionId), sessionId
+}
+
+// outc
+//
+// @param kv 本条日志额外的键值对，跟在context中的键值对后面输出
+func (l *logger) outc(ctx context.Context, level Level, calldepth int, msg string, kv []interface{}) {
+	// 先判断级别，避免被过滤掉的日志还要合并键值对
+	if !l.isEnabled(level) {
+		return
+	}
+	if fields := FieldsFromContext(ctx); len(fields) != 0 {
+		merged := make([]interface{}, 0, len(fields)+len(kv))
+		merged = append(merged, fields...)
+		kv = append(merged, kv...)
+	}
+	if !l.allow(level, msg, calldepth+1) {
+		return
+	}
+	// `ctx`传递给 backend ，比如 NewSlogLogger 中的 slog.Handler 可以从中获取链路追踪等信息
+	l.output(ctx, level, calldepth+1, msg, kv)
+}
@@ -0,0 +1,70 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func TestContext(t *testing.T) {
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatLogfmt, &lines, func(option *nazalog.Option) {
+		option.LevelFlag = false
+	})
+
+	// 没有保存Logger对象时使用全局Logger对象
+	assert.Equal(t, nazalog.GetGlobalLogger(), nazalog.FromContext(context.Background()))
+
+	ctx := nazalog.NewContext(context.Background(), l.WithPrefix("rtmp"))
+	ctx, traceId := nazalog.ContextWithTraceId(ctx, "")
+	assert.Equal(t, true, strings.HasPrefix(traceId, "TRACE"), traceId)
+	ctx, _ = nazalog.ContextWithSessionId(ctx, "s1")
+
+	// Logger对象和键值对跟随ctx在协程间传递
+	done := make(chan struct{})
+	go func(ctx context.Context) {
+		nazalog.Infoc(ctx, "publish", "stream", "test")
+		nazalog.FromContext(ctx).Warnc(context.Background(), "no fields")
+		close(done)
+	}(ctx)
+	<-done
+
+	// 子ctx添加的键值对不影响父ctx
+	child := nazalog.ContextWithFields(ctx, "k", 1)
+	l.Debugc(child, "child")
+	l.Debugc(ctx, "parent")
+
+	assert.Equal(t, []string{
+		"prefix=rtmp msg=publish trace_id=" + traceId + " session_id=s1 stream=test\n",
+		"prefix=rtmp msg=\"no fields\"\n",
+		"msg=child trace_id=" + traceId + " session_id=s1 k=1\n",
+		"msg=parent trace_id=" + traceId + " session_id=s1\n",
+	}, lines)
+}
+
+func TestContext_Caller(t *testing.T) {
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatText, &lines, func(option *nazalog.Option) {
+		option.LevelFlag = false
+		option.ShortFileFlag = true
+	})
+	ctx := nazalog.NewContext(context.Background(), l)
+	nazalog.Infoc(ctx, "a")
+	l.Infoc(ctx, "b")
+	nazalog.Outc(ctx, nazalog.LevelInfo, 2, "c")
+	assert.Equal(t, 3, len(lines))
+	for _, line := range lines {
+		assert.Equal(t, true, strings.Contains(line, " - context_test.go:"), line)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/q191201771/naza/pkg/bininfo"
+)
+
+const crashSuffix = ".crash"
+
+// maxStackBufSize 获取所有协程调用栈时，最多使用的内存
+const maxStackBufSize = 64 * 1024 * 1024
+
+// newCrashSink 开启 Option.IsCrashDump 时，用于保存最近 Option.CrashLastLines 条日志的输出目标
+//
+// @return 不需要时返回nil
+func newCrashSink(option Option) *sink {
+	if !option.IsCrashDump || option.CrashLastLines <= 0 {
+		return nil
+	}
+	s := &sink{
+		memory: NewMemorySink(func(mo *MemorySinkOption) {
+			mo.MaxLines = option.CrashLastLines
+		}),
+	}
+	// 主格式输出至控制台时使用彩色，此时单独格式化一份
+	if option.IsToStdout {
+		s.format = option.Format
+	}
+	return s
+}
+
+// crashFilename 崩溃信息文件的文件名，为空表示不写崩溃信息文件
+func crashFilename(option Option) string {
+	if option.CrashFilename != "" {
+		return option.CrashFilename
+	}
+	if option.Filename != "" {
+		return option.Filename + crashSuffix
+	}
+	return ""
+}
+
+// writeCrashDump 将崩溃信息写入所有日志文件，以及崩溃信息文件
+//
+// 如果既没有日志文件，也没有崩溃信息文件，则写入stderr
+func (c *core) writeCrashDump(level Level, now time.Time, msg string) {
+	b := c.buildCrashDump(level, now, msg)
+
+	c.m.Lock()
+	hasFile := false
+	for _, s := range c.sinks {
+		if s.file != nil {
+			s.flushBatch()
+			_ = s.file.write(now, b)
+			s.file.sync()
+			hasFile = true
+		}
+	}
+	c.m.Unlock()
+
+	filename := crashFilename(c.option)
+	if filename == "" {
+		if !hasFile {
+			_, _ = os.Stderr.Write(b)
+		}
+		return
+	}
+	if err := appendCrashFile(filename, b); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "write crash file error. err=%+v, filename=%s", err, filename)
+	}
+}
+
+func (c *core) buildCrashDump(level Level, now time.Time, msg string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("==================== nazalog crash dump ====================\n")
+	_, _ = fmt.Fprintf(&buf, "time=%s\nlevel=%s\nmsg=%s\n", formatTimestamp(now, true), levelName(level), msg)
+
+	buf.WriteString("-------------------- bininfo --------------------\n")
+	buf.WriteString(bininfo.StringifyMultiLine())
+
+	if c.crashSink != nil {
+		records := c.crashSink.memory.Query(MemoryQuery{})
+		_, _ = fmt.Fprintf(&buf, "-------------------- last %d lines --------------------\n", len(records))
+		for _, r := range records {
+			buf.WriteString(r.Line)
+			buf.WriteByte('\n')
+		}
+	}
+
+	buf.WriteString("-------------------- goroutines --------------------\n")
+	buf.Write(allStacks())
+	buf.WriteString("==================== end of crash dump ====================\n")
+	return buf.Bytes()
+}
+
+// allStacks 所有协程的调用栈，见 runtime.Stack
+func allStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxStackBufSize {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+func appendCrashFile(filename string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+		return err
+	}
+	fp, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err = fp.Write(b); err != nil {
+		_ = fp.Close()
+		return err
+	}
+	_ = fp.Sync()
+	return fp.Close()
+}
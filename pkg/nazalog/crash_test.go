@@ -0,0 +1,104 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/fake"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func TestCrashDump(t *testing.T) {
+	for _, isAsync := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "nazalogtest")
+		assert.Equal(t, nil, err)
+		filename := filepath.Join(dir, "app.log")
+
+		l, err := nazalog.New(func(option *nazalog.Option) {
+			option.Filename = filename
+			option.IsToStdout = false
+			option.IsAsync = isAsync
+			option.IsCrashDump = true
+			option.CrashLastLines = 2
+		})
+		assert.Equal(t, nil, err)
+
+		l.Info("line a")
+		l.Info("line b")
+		er := fake.WithFakeOsExit(func() {
+			l.Fatal("boom")
+		})
+		assert.Equal(t, true, er.HasExit)
+		assert.Equal(t, 1, er.ExitCode)
+
+		b, err := ioutil.ReadFile(filename + ".crash")
+		assert.Equal(t, nil, err)
+		dump := string(b)
+		assert.Equal(t, true, strings.HasPrefix(dump, "==================== nazalog crash dump"), dump)
+		assert.Equal(t, true, strings.Contains(dump, "level=FATAL\nmsg=boom\n"), dump)
+		assert.Equal(t, true, strings.Contains(dump, "GitTag="), dump)
+		// 最近的日志包含Fatal日志自身
+		assert.Equal(t, true, strings.Contains(dump, "last 2 lines"), dump)
+		assert.Equal(t, false, strings.Contains(dump, "line a"), dump)
+		assert.Equal(t, true, strings.Contains(dump, "line b"), dump)
+		assert.Equal(t, true, strings.Contains(dump, "FATAL boom"), dump)
+		assert.Equal(t, true, strings.Contains(dump, "TestCrashDump"), dump)
+
+		// 日志文件中也有崩溃信息，并且在Fatal日志之后
+		b, err = ioutil.ReadFile(filename)
+		assert.Equal(t, nil, err)
+		log := string(b)
+		assert.Equal(t, true, strings.Index(log, "FATAL boom") < strings.Index(log, "nazalog crash dump"), log)
+
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestCrashDump_Assert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nazalogtest")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	crashFilename := filepath.Join(dir, "crash", "app.crash")
+
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.IsToStdout = false
+		option.AssertBehavior = nazalog.AssertPanic
+		option.IsCrashDump = true
+		option.CrashFilename = crashFilename
+		option.CrashLastLines = 0
+	})
+	assert.Equal(t, nil, err)
+
+	func() {
+		defer func() {
+			assert.Equal(t, true, recover() != nil)
+		}()
+		l.Assert(1, 2)
+	}()
+	// 没有失败的断言不输出崩溃信息
+	l.Assert(1, 1)
+
+	b, err := ioutil.ReadFile(crashFilename)
+	assert.Equal(t, nil, err)
+	dump := string(b)
+	assert.Equal(t, 1, strings.Count(dump, "nazalog crash dump"))
+	assert.Equal(t, true, strings.Contains(dump, "level=PANIC\n"), dump)
+	assert.Equal(t, false, strings.Contains(dump, "last 0 lines"), dump)
+
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.CrashLastLines = -1
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+}
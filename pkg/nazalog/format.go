@@ -0,0 +1,269 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 键值对中缺少键时使用的键名，比如`kv`的个数为奇数，或者键不是string类型
+const badKey = "!BADKEY"
+
+// json和logfmt格式下各字段的键名
+const (
+	keyTime   = "time"
+	keyLevel  = "level"
+	keyPrefix = "prefix"
+	keyMsg    = "msg"
+	keyCaller = "caller"
+)
+
+// formatJson 格式化为一行json，比如
+// {"time":"2026-10-18T12:00:00.000000+08:00","level":"INFO","prefix":["a","b"],"msg":"hello","k":1,"caller":"log_test.go:10"}
+func (l *logger) formatJson(buf *bytes.Buffer, level Level, now time.Time, file string, line int, s string, kv []interface{}) {
+	buf.WriteByte('{')
+	first := true
+	writeKey := func(key string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeJsonString(buf, key)
+		buf.WriteByte(':')
+	}
+
+	if l.core.option.TimestampFlag {
+		writeKey(keyTime)
+		writeJsonString(buf, formatTimestamp(now, l.core.option.TimestampWithMsFlag))
+	}
+	if l.core.option.LevelFlag {
+		writeKey(keyLevel)
+		writeJsonString(buf, levelName(level))
+	}
+	if len(l.prefixs) != 0 {
+		writeKey(keyPrefix)
+		buf.WriteByte('[')
+		for i, prefix := range l.prefixs {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			writeJsonString(buf, prefix)
+		}
+		buf.WriteByte(']')
+	}
+	writeKey(keyMsg)
+	writeJsonString(buf, strings.TrimRight(s, "\n"))
+	rangeFields(l.fields, kv, func(key string, value interface{}) {
+		writeKey(key)
+		writeJsonValue(buf, value)
+	})
+	if file != "" && line > 0 {
+		writeKey(keyCaller)
+		writeJsonString(buf, shortFile(file)+":"+strconv.Itoa(line))
+	}
+	buf.WriteString("}\n")
+}
+
+// formatLogfmt 格式化为一行logfmt，比如
+// time=2026-10-18T12:00:00.000000+08:00 level=INFO prefix=a,b msg=hello k=1 caller=log_test.go:10
+func (l *logger) formatLogfmt(buf *bytes.Buffer, level Level, now time.Time, file string, line int, s string, kv []interface{}) {
+	first := true
+	write := func(key string, value interface{}) {
+		if !first {
+			buf.WriteByte(' ')
+		}
+		first = false
+		writeLogfmtField(buf, key, value)
+	}
+
+	if l.core.option.TimestampFlag {
+		write(keyTime, formatTimestamp(now, l.core.option.TimestampWithMsFlag))
+	}
+	if l.core.option.LevelFlag {
+		write(keyLevel, levelName(level))
+	}
+	if len(l.prefixs) != 0 {
+		write(keyPrefix, strings.Join(l.prefixs, ","))
+	}
+	write(keyMsg, strings.TrimRight(s, "\n"))
+	rangeFields(l.fields, kv, write)
+	if file != "" && line > 0 {
+		write(keyCaller, shortFile(file)+":"+strconv.Itoa(line))
+	}
+	buf.WriteByte('\n')
+}
+
+// rangeFields 依次遍历`fields`和`kv`中的键值对
+//
+// 规则与标准库log/slog一致：如果当前元素是string并且后面还有元素，则两者组成一个键值对，
+// 否则当前元素作为值，键为 badKey
+func rangeFields(fields []interface{}, kv []interface{}, fn func(key string, value interface{})) {
+	for _, list := range [2][]interface{}{fields, kv} {
+		for i := 0; i < len(list); i++ {
+			if key, ok := list[i].(string); ok && i+1 < len(list) {
+				fn(key, list[i+1])
+				i++
+				continue
+			}
+			fn(badKey, list[i])
+		}
+	}
+}
+
+func writeLogfmtField(buf *bytes.Buffer, key string, value interface{}) {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c <= ' ' || c == '=' || c == '"' {
+			c = '_'
+		}
+		buf.WriteByte(c)
+	}
+	buf.WriteByte('=')
+
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if needLogfmtQuote(s) {
+		buf.WriteString(strconv.Quote(s))
+	} else {
+		buf.WriteString(s)
+	}
+}
+
+func needLogfmtQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJsonValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case string:
+		writeJsonString(buf, v)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case int:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case int32:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case uint:
+		buf.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint32:
+		buf.WriteString(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(v, 10))
+	case error:
+		writeJsonString(buf, v.Error())
+	case json.Marshaler:
+		writeJsonMarshal(buf, v)
+	case fmt.Stringer:
+		writeJsonString(buf, v.String())
+	default:
+		writeJsonMarshal(buf, v)
+	}
+}
+
+// writeJsonMarshal 序列化失败时（比如float的NaN），退化为输出字符串
+func writeJsonMarshal(buf *bytes.Buffer, value interface{}) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		writeJsonString(buf, fmt.Sprintf("%+v", value))
+		return
+	}
+	buf.Write(b)
+}
+
+const hex = "0123456789abcdef"
+
+func writeJsonString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.WriteString(s[start:i])
+				buf.WriteString(`\ufffd`)
+				i += size
+				start = i
+				continue
+			}
+			i += size
+			continue
+		}
+		if c >= ' ' && c != '"' && c != '\\' {
+			i++
+			continue
+		}
+		buf.WriteString(s[start:i])
+		switch c {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xF])
+		}
+		i++
+		start = i
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+func formatTimestamp(t time.Time, withMs bool) string {
+	if withMs {
+		return t.Format("2006-01-02T15:04:05.000000Z07:00")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// levelName 比如"INFO"
+func levelName(level Level) string {
+	return strings.TrimSpace(levelToString[level])
+}
+
+func shortFile(file string) string {
+	for i := len(file) - 1; i > 0; i-- {
+		if file[i] == '/' {
+			return file[i+1:]
+		}
+	}
+	return file
+}
@@ -0,0 +1,166 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/fake"
+	"github.com/q191201771/naza/pkg/mock"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func newCaptureLogger(t *testing.T, format nazalog.Format, lines *[]string, modOptions ...nazalog.ModOption) nazalog.Logger {
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.IsToStdout = false
+		option.Format = format
+		option.ShortFileFlag = false
+		option.TimestampFlag = false
+		option.HookBackendOutFn = func(level nazalog.Level, line []byte) {
+			*lines = append(*lines, string(line))
+		}
+		for _, fn := range modOptions {
+			fn(option)
+		}
+	})
+	assert.Equal(t, nil, err)
+	return l
+}
+
+func TestFormatText(t *testing.T) {
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatText, &lines, func(option *nazalog.Option) {
+		option.LevelFlag = false
+	})
+
+	l.Info("plain")
+	l.Infow("request done", "uri", "/index", "cost", 10*time.Millisecond, "err", errors.New("not found"))
+	l.With("conn", 1).WithPrefix("rtmp").Infow("closed", "reason", "a b")
+	l.Infow("odd", "k", 1, 2)
+	assert.Equal(t, []string{
+		"plain\n",
+		"request done uri=/index cost=10ms err=\"not found\"\n",
+		"[rtmp] closed conn=1 reason=\"a b\"\n",
+		"odd k=1 !BADKEY=2\n",
+	}, lines)
+}
+
+func TestFormatJson(t *testing.T) {
+	nazalog.Clock = mock.NewFakeClock()
+	defer func() {
+		nazalog.Clock = mock.NewStdClock()
+	}()
+	nazalog.Clock.Set(time.Date(2026, 10, 18, 12, 0, 0, 123456000, time.UTC))
+
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatJson, &lines, func(option *nazalog.Option) {
+		option.TimestampFlag = true
+		option.ShortFileFlag = true
+	})
+
+	l.WithPrefix("a").WithPrefix("b").With("conn", 1).Infow("hello \"world\"\n", "n", math.NaN(), "err", errors.New("x"), "ok", true, "m", map[string]int{"x": 1})
+	assert.Equal(t, 1, len(lines))
+	assert.Equal(t, true, strings.HasSuffix(lines[0], "}\n"))
+
+	var m map[string]interface{}
+	assert.Equal(t, nil, json.Unmarshal([]byte(lines[0]), &m))
+	assert.Equal(t, "2026-10-18T12:00:00.123456Z", m["time"])
+	assert.Equal(t, "INFO", m["level"])
+	assert.Equal(t, []interface{}{"a", "b"}, m["prefix"])
+	assert.Equal(t, "hello \"world\"", m["msg"])
+	assert.Equal(t, float64(1), m["conn"])
+	assert.Equal(t, "NaN", m["n"])
+	assert.Equal(t, "x", m["err"])
+	assert.Equal(t, true, m["ok"])
+	assert.Equal(t, map[string]interface{}{"x": float64(1)}, m["m"])
+	assert.Equal(t, true, strings.HasPrefix(m["caller"].(string), "format_test.go:"))
+
+	// 控制字符和非法utf8
+	lines = nil
+	l.Info("\x01\xff")
+	assert.Equal(t, nil, json.Unmarshal([]byte(lines[0]), &m))
+	assert.Equal(t, "\x01�", m["msg"])
+}
+
+func TestFormatLogfmt(t *testing.T) {
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatLogfmt, &lines)
+
+	l.WithPrefix("a").WithPrefix("b").Warnw("hello world", "k=1", "", "v", 1.5)
+	defer nazalog.SetGlobalLogger(nazalog.GetGlobalLogger())
+	nazalog.SetGlobalLogger(l)
+	nazalog.With("g", 1).Errorw("global")
+	nazalog.Infow("global", "x", nil)
+	assert.Equal(t, []string{
+		"level=WARN prefix=a,b msg=\"hello world\" k_1=\"\" v=1.5\n",
+		"level=ERROR msg=global g=1\n",
+		"level=INFO msg=global x=<nil>\n",
+	}, lines)
+}
+
+func TestFormat_Caller(t *testing.T) {
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatLogfmt, &lines, func(option *nazalog.Option) {
+		option.ShortFileFlag = true
+	})
+	defer nazalog.SetGlobalLogger(nazalog.GetGlobalLogger())
+	nazalog.SetGlobalLogger(l)
+
+	l.Infow("1")
+	l.Outw(nazalog.LevelInfo, 1, "2")
+	l.Out(nazalog.LevelInfo, 1, "3")
+	l.Infof("4")
+	nazalog.Infow("5")
+	nazalog.Infof("6")
+	for _, line := range lines {
+		assert.Equal(t, true, strings.Contains(line, "caller=format_test.go:"), line)
+	}
+
+	er := fake.WithFakeOsExit(func() {
+		l.Fatalw("fatal", "k", "v")
+	})
+	assert.Equal(t, true, er.HasExit)
+	fake.WithRecover(func() {
+		nazalog.Panicw("panic", "k", "v")
+	})
+	assert.Equal(t, 8, len(lines))
+}
+
+func TestFormat_Validate(t *testing.T) {
+	_, err := nazalog.New(func(option *nazalog.Option) {
+		option.Format = nazalog.FormatLogfmt + 1
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+
+	// 整体覆盖 Option ，并且配置中没有format字段时，使用 FormatText
+	var cfg nazalog.Option
+	err = json.Unmarshal([]byte(`{"level": 1, "is_to_stdout": false, "assert_behavior": 1}`), &cfg)
+	assert.Equal(t, nil, err)
+	var lines []string
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		*option = cfg
+		option.HookBackendOutFn = func(level nazalog.Level, line []byte) {
+			lines = append(lines, string(line))
+		}
+	})
+	assert.Equal(t, nil, err)
+	l.Infow("hello", "k", 1)
+	assert.Equal(t, []string{"hello k=1\n"}, lines)
+
+	assert.Equal(t, "FormatText", nazalog.FormatText.ReadableString())
+	assert.Equal(t, "FormatJson", nazalog.FormatJson.ReadableString())
+	assert.Equal(t, "FormatLogfmt", nazalog.FormatLogfmt.ReadableString())
+	assert.Equal(t, "unknown", nazalog.Format(100).ReadableString())
+}
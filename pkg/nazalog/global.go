@@ -9,7 +9,9 @@
 package nazalog
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/q191201771/naza/pkg/nazareflect"
 
@@ -19,32 +21,32 @@ import (
 var global Logger
 
 func Tracef(format string, v ...interface{}) {
-	global.Out(LevelTrace, 2, fmt.Sprintf(format, v...))
+	global.Outf(LevelTrace, 2, format, v...)
 }
 
 func Debugf(format string, v ...interface{}) {
-	global.Out(LevelDebug, 2, fmt.Sprintf(format, v...))
+	global.Outf(LevelDebug, 2, format, v...)
 }
 
 func Infof(format string, v ...interface{}) {
-	global.Out(LevelInfo, 2, fmt.Sprintf(format, v...))
+	global.Outf(LevelInfo, 2, format, v...)
 }
 
 func Warnf(format string, v ...interface{}) {
-	global.Out(LevelWarn, 2, fmt.Sprintf(format, v...))
+	global.Outf(LevelWarn, 2, format, v...)
 }
 
 func Errorf(format string, v ...interface{}) {
-	global.Out(LevelError, 2, fmt.Sprintf(format, v...))
+	global.Outf(LevelError, 2, format, v...)
 }
 
 func Fatalf(format string, v ...interface{}) {
-	global.Out(LevelFatal, 2, fmt.Sprintf(format, v...))
+	global.Outf(LevelFatal, 2, format, v...)
 	fake.Os_Exit(1)
 }
 
 func Panicf(format string, v ...interface{}) {
-	global.Out(LevelPanic, 2, fmt.Sprintf(format, v...))
+	global.Outf(LevelPanic, 2, format, v...)
 	panic(fmt.Sprintf(format, v...))
 }
 
@@ -78,6 +80,66 @@ func Panic(v ...interface{}) {
 	panic(fmt.Sprint(v...))
 }
 
+func Tracew(msg string, kv ...interface{}) {
+	global.Outw(LevelTrace, 2, msg, kv...)
+}
+
+func Debugw(msg string, kv ...interface{}) {
+	global.Outw(LevelDebug, 2, msg, kv...)
+}
+
+func Infow(msg string, kv ...interface{}) {
+	global.Outw(LevelInfo, 2, msg, kv...)
+}
+
+func Warnw(msg string, kv ...interface{}) {
+	global.Outw(LevelWarn, 2, msg, kv...)
+}
+
+func Errorw(msg string, kv ...interface{}) {
+	global.Outw(LevelError, 2, msg, kv...)
+}
+
+func Fatalw(msg string, kv ...interface{}) {
+	global.Outw(LevelFatal, 2, msg, kv...)
+	fake.Os_Exit(1)
+}
+
+func Panicw(msg string, kv ...interface{}) {
+	global.Outw(LevelPanic, 2, msg, kv...)
+	panic(msg)
+}
+
+func Tracec(ctx context.Context, msg string, kv ...interface{}) {
+	FromContext(ctx).Outc(ctx, LevelTrace, 2, msg, kv...)
+}
+
+func Debugc(ctx context.Context, msg string, kv ...interface{}) {
+	FromContext(ctx).Outc(ctx, LevelDebug, 2, msg, kv...)
+}
+
+func Infoc(ctx context.Context, msg string, kv ...interface{}) {
+	FromContext(ctx).Outc(ctx, LevelInfo, 2, msg, kv...)
+}
+
+func Warnc(ctx context.Context, msg string, kv ...interface{}) {
+	FromContext(ctx).Outc(ctx, LevelWarn, 2, msg, kv...)
+}
+
+func Errorc(ctx context.Context, msg string, kv ...interface{}) {
+	FromContext(ctx).Outc(ctx, LevelError, 2, msg, kv...)
+}
+
+func Fatalc(ctx context.Context, msg string, kv ...interface{}) {
+	FromContext(ctx).Outc(ctx, LevelFatal, 2, msg, kv...)
+	fake.Os_Exit(1)
+}
+
+func Panicc(ctx context.Context, msg string, kv ...interface{}) {
+	FromContext(ctx).Outc(ctx, LevelPanic, 2, msg, kv...)
+	panic(msg)
+}
+
 func Output(calldepth int, s string) error {
 	global.Out(LevelInfo, calldepth, s)
 	return nil
@@ -88,7 +150,7 @@ func Print(v ...interface{}) {
 }
 
 func Printf(format string, v ...interface{}) {
-	global.Out(LevelInfo, 2, fmt.Sprintf(format, v...))
+	global.Outf(LevelInfo, 2, format, v...)
 }
 func Println(v ...interface{}) {
 	global.Out(LevelInfo, 2, fmt.Sprint(v...))
@@ -127,14 +189,59 @@ func Out(level Level, calldepth int, s string) {
 	global.Out(level, calldepth, s)
 }
 
+func Outf(level Level, calldepth int, format string, v ...interface{}) {
+	global.Outf(level, calldepth, format, v...)
+}
+
+func Outw(level Level, calldepth int, msg string, kv ...interface{}) {
+	global.Outw(level, calldepth, msg, kv...)
+}
+
+// Outc 使用 FromContext 获取的Logger对象打印日志
+func Outc(ctx context.Context, level Level, calldepth int, msg string, kv ...interface{}) {
+	FromContext(ctx).Outc(ctx, level, calldepth, msg, kv...)
+}
+
 func Sync() {
 	global.Sync()
 }
 
+func GetDroppedNum() uint64 {
+	return global.GetDroppedNum()
+}
+
 func WithPrefix(s string) Logger {
 	return global.WithPrefix(s)
 }
 
+func With(kv ...interface{}) Logger {
+	return global.With(kv...)
+}
+
+func LogEvery(d time.Duration) Logger {
+	return global.LogEvery(d)
+}
+
+func LogFirstN(n int) Logger {
+	return global.LogFirstN(n)
+}
+
+func SetLevel(level Level) error {
+	return global.SetLevel(level)
+}
+
+func SetPrefixLevel(prefix string, level Level) error {
+	return global.SetPrefixLevel(prefix, level)
+}
+
+func DeletePrefixLevel(prefix string) {
+	global.DeletePrefixLevel(prefix)
+}
+
+func GetPrefixLevels() map[string]Level {
+	return global.GetPrefixLevels()
+}
+
 func GetOption() Option {
 	return global.GetOption()
 }
@@ -9,13 +9,17 @@
 // Package nazalog 日志库
 package nazalog
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // 这是一个以使用方便为主要目标的日志库，特性：
 //
 // * 带日志级别
-// * 可选输出至控制台或文件，也可以同时输出
-// * 日志文件支持按天翻转
+// * 可选输出至控制台或文件，也可以同时输出，还可以添加多个有独立日志级别和格式的输出目标
+// * 日志文件支持按天、按小时、按大小翻转，支持压缩备份文件，按天数和个数清理备份文件
 // * 支持是否输出源码文件及行号
 // * 业务日志起始位置固定，方便查看
 // * 支持Assert，断言失败后的行为可配置
@@ -23,10 +27,15 @@ import "errors"
 // * 支持设置前缀，并且前缀可叠加，使得可以按repo ，package，对象等维度添加不同的前缀
 // * 支持标准库中的打印接口函数（但是没有适配非打印接口），方便替换标准库日志
 // * 日志文件目录不存在则自动创建
+// * 支持异步输出，业务协程不会被慢速的磁盘阻塞
+// * 支持运行时修改日志级别，支持按前缀单独设置日志级别，可通过http查看和修改
+// * 支持键值对形式的结构化日志，支持text、json、logfmt格式输出
+// * 支持采样，避免热点路径上的相同日志刷屏
+// * 支持通过context.Context传递Logger对象和trace id等键值对
+// * 支持和标准库log/slog互相适配（Go 1.21及以上）
+// * 支持在Fatal、Panic时输出所有协程的调用栈等崩溃信息
 //
 // 目前性能和标准库log相当
-//
-// TODO(chef): 异步日志
 
 var ErrLog = errors.New("naza.log:fxxk")
 
@@ -47,7 +56,34 @@ type Logger interface {
 	Fatal(v ...interface{})
 	Panic(v ...interface{})
 
+	// Tracew ... 结构化日志，`kv`为键值对，比如 Infow("request done", "uri", uri, "cost", cost)
+	//
+	// 键应为string类型，如果`kv`的个数为奇数，或者键不是string类型，则使用"!BADKEY"作为键
+	//
+	Tracew(msg string, kv ...interface{})
+	Debugw(msg string, kv ...interface{})
+	Infow(msg string, kv ...interface{})
+	Warnw(msg string, kv ...interface{})
+	Errorw(msg string, kv ...interface{})
+	Fatalw(msg string, kv ...interface{})
+	Panicw(msg string, kv ...interface{})
+
+	// Tracec ... 结构化日志，和 Tracew ... 的区别是，先输出`ctx`中通过 ContextWithFields 添加的键值对（比如trace id），再输出`kv`
+	//
+	// 包级别的 Tracec ... 函数使用 FromContext 获取的Logger对象打印日志，使得Logger对象可以跟随请求在协程间传递
+	//
+	Tracec(ctx context.Context, msg string, kv ...interface{})
+	Debugc(ctx context.Context, msg string, kv ...interface{})
+	Infoc(ctx context.Context, msg string, kv ...interface{})
+	Warnc(ctx context.Context, msg string, kv ...interface{})
+	Errorc(ctx context.Context, msg string, kv ...interface{})
+	Fatalc(ctx context.Context, msg string, kv ...interface{})
+	Panicc(ctx context.Context, msg string, kv ...interface{})
+
 	Out(level Level, calldepth int, s string)
+	Outf(level Level, calldepth int, format string, v ...interface{})
+	Outw(level Level, calldepth int, msg string, kv ...interface{})
+	Outc(ctx context.Context, level Level, calldepth int, msg string, kv ...interface{})
 
 	// Assert 断言失败后的行为由配置项Option.AssertBehavior决定
 	// 注意，expected和actual的类型必须相同，比如int(1)和int32(1)是不相等的
@@ -62,6 +98,10 @@ type Logger interface {
 	//
 	Sync()
 
+	// GetDroppedNum 异步模式下，由于缓冲区满而被丢弃的日志条数，见 Option.AsyncOverflowBehavior
+	//
+	GetDroppedNum() uint64
+
 	// WithPrefix
 	//
 	// 添加前缀，新生成一个Logger对象，如果老Logger也有prefix，则老Logger依然打印老prefix，新Logger打印多个prefix。
@@ -70,6 +110,37 @@ type Logger interface {
 	//
 	WithPrefix(s string) Logger
 
+	// With
+	//
+	// 添加键值对，新生成一个Logger对象，新Logger打印的每条日志都会带上这些键值对，规则同 Infow
+	//
+	// 返回的Logger对象是新的，底层的 core 是同一个
+	//
+	With(kv ...interface{}) Logger
+
+	// LogEvery
+	//
+	// 采样，新生成一个Logger对象，同一条日志（按日志模板区分，见 Option.SampleIntervalMs ）每`d`时间内最多输出一次，
+	// 周期结束时输出该周期内被丢弃的条数
+	//
+	// 注意，只有 Infof 等带`format`的函数，内容不同的日志才共享同一个模板。
+	// Info 等函数使用格式化后的内容作为模板，如果内容是动态的，则每条日志都单独计数，起不到采样的作用
+	//
+	// 返回的Logger对象是新的，底层的 core 是同一个，可以在每次打印时调用，比如 nazalog.LogEvery(time.Second).Errorf(...)
+	//
+	LogEvery(d time.Duration) Logger
+
+	// LogFirstN
+	//
+	// 采样，新生成一个Logger对象，同一条日志只输出前`n`次，被丢弃的条数在 Sync 时输出
+	// 日志模板的注意事项同 LogEvery
+	//
+	// 注意，为了限制内存占用，不同日志模板的计数个数超过上限（4096）时，所有计数会被清空，之后会重新输出前`n`次
+	//
+	// 返回的Logger对象是新的，底层的 core 是同一个
+	//
+	LogFirstN(n int) Logger
+
 	// Output Print ... 下面这些打印接口是为兼容标准库，让某些已使用标准库日志的代码替换到nazalog方便一些
 	//
 	Output(calldepth int, s string) error
@@ -79,6 +150,26 @@ type Logger interface {
 	Fatalln(v ...interface{})
 	Panicln(v ...interface{})
 
+	// SetLevel 修改日志级别，可以和其他函数并发调用
+	//
+	// @return 如果`level`不是合法的日志级别，返回 ErrLog ，日志级别保持不变
+	//
+	SetLevel(level Level) error
+
+	// SetPrefixLevel 单独设置前缀为`prefix`的Logger的日志级别，可以和其他函数并发调用，见 Option.PrefixLevels
+	//
+	// @return 如果`level`不是合法的日志级别，返回 ErrLog ，不做修改
+	//
+	SetPrefixLevel(prefix string, level Level) error
+
+	// DeletePrefixLevel 删除 SetPrefixLevel 的设置，该前缀的Logger恢复使用 SetLevel 设置的日志级别
+	//
+	DeletePrefixLevel(prefix string)
+
+	// GetPrefixLevels 获取所有单独设置了日志级别的前缀
+	//
+	GetPrefixLevels() map[string]Level
+
 	// GetOption 获取配置项
 	//
 	// 注意，作用是只读，非修改配置
@@ -96,16 +187,28 @@ type Logger interface {
 type HookBackendOutFn func(level Level, line []byte)
 
 type Option struct {
-	Level Level `json:"level"` // 日志级别，大于等于该级别的日志才会被输出
+	Level Level `json:"level"` // 日志级别，大于等于该级别的日志才会被输出。运行时可通过 Logger.SetLevel 修改
+
+	// PrefixLevels 按前缀（见 Logger.WithPrefix ）单独设置的日志级别，比如{"rtmp": LevelTrace}，
+	// 有多个前缀时，使用最后添加的前缀对应的级别。运行时可通过 Logger.SetPrefixLevel 修改
+	PrefixLevels map[string]Level `json:"prefix_levels"`
 
 	// 文件输出和控制台输出可同时打开
 	// 控制台输出主要用做开发时调试，打开后level字段使用彩色输出
 	Filename   string `json:"filename"`     // 输出日志文件名，如果为空，则不写日志文件。可包含路径，路径不存在时，将自动创建
-	IsToStdout bool   `json:"is_to_stdout"` // 是否以stdout输出到控制台 TODO(chef): 再增加一个stderr的配置
+	IsToStdout bool   `json:"is_to_stdout"` // 是否以stdout输出到控制台。如果需要输出至stderr，可使用 Sinks
+
+	// Sinks 额外的输出目标，每个目标可以配置自己的日志级别和格式，比如只记录错误日志的文件，stderr，用户提供的 io.Writer ，用于查询最近日志的 MemorySink 等
+	Sinks []SinkOption `json:"sinks"`
 
 	IsRotateDaily  bool `json:"is_rotate_daily"`  // 日志按天翻转
 	IsRotateHourly bool `json:"is_rotate_hourly"` // 日志按小时翻滚，整点翻滚
-	MaxRotateDays  int  `json:"max_rotate_days"`  // 日志最大存储天数
+	MaxRotateDays  int  `json:"max_rotate_days"`  // 日志最大存储天数，超过的备份文件将被删除，为0则不按天数删除
+
+	// 备份文件的命名为`Filename`.时间[.序号][.gz]
+	MaxFileSizeMB    int  `json:"max_file_size_mb"`   // 日志文件超过该大小时翻滚，单位MB，为0则不按大小翻滚。可以和按天、按小时翻滚同时使用
+	MaxBackups       int  `json:"max_backups"`        // 最多保留的备份文件个数，超过时删除最老的，为0则不限制
+	IsCompressBackup bool `json:"is_compress_backup"` // 是否在后台将翻滚出的备份文件压缩为gzip格式
 
 	ShortFileFlag       bool `json:"short_file_flag"`        // 是否在每行日志尾部添加源码文件及行号的信息
 	TimestampFlag       bool `json:"timestamp_flag"`         // 是否在每行日志首部添加时间戳的信息
@@ -114,6 +217,13 @@ type Option struct {
 
 	AssertBehavior AssertBehavior `json:"assert_behavior"` // 断言失败时的行为
 
+	// Format 日志格式
+	//
+	// FormatJson 和 FormatLogfmt 格式下，时间戳、日志级别、前缀、源码文件及行号、键值对都作为独立的字段输出，
+	// 是否输出时间戳等字段依然由上面的各Flag配置项决定，日志级别不使用彩色。
+	// 为0则使用 FormatText
+	Format Format `json:"format"`
+
 	// HookBackendOutFn
 	//
 	// hook后端输出的日志内容。
@@ -125,20 +235,63 @@ type Option struct {
 	// 阻塞函数。
 	// 回调结束后，内部会服用回调中日志内容的内存块。
 	HookBackendOutFn HookBackendOutFn
+
+	// IsAsync 是否开启异步模式
+	//
+	// 开启后，业务协程只负责格式化日志并放入缓冲区，由独立的协程批量输出至控制台和日志文件，
+	// 此时 HookBackendOutFn 也在该协程中回调。
+	// 调用 Sync ，以及打印Fatal、Panic级别的日志时，会等待缓冲区中的日志全部输出完毕。
+	IsAsync               bool                  `json:"is_async"`
+	AsyncBufferSize       int                   `json:"async_buffer_size"`       // 异步模式下缓冲区最多容纳的日志条数
+	AsyncOverflowBehavior AsyncOverflowBehavior `json:"async_overflow_behavior"` // 异步模式下缓冲区满时的行为
+
+	// SampleIntervalMs 采样周期，单位毫秒，为0则不采样
+	//
+	// 开启后，同一级别、同一日志模板的日志，在每个周期内先输出前 SampleFirst 条，之后每 SampleThereafter 条输出一条，
+	// 其余的丢弃，周期结束时输出被丢弃的条数。
+	// 日志模板指 Infof 等函数的`format`参数， Info 等函数格式化后的内容， Infow 等函数的`msg`参数。
+	// 所以只有 Infof 等函数，内容不同的日志才共享同一个模板。
+	// Fatal、Panic级别的日志不采样。
+	// 也可以通过 Logger.LogEvery 和 Logger.LogFirstN 只对部分日志采样。
+	SampleIntervalMs int `json:"sample_interval_ms"`
+	SampleFirst      int `json:"sample_first"`      // 每个采样周期内最先输出的条数
+	SampleThereafter int `json:"sample_thereafter"` // 超过 SampleFirst 后，每多少条输出一条，为0则都丢弃
+
+	// IsCrashDump 是否在输出Fatal、Panic级别的日志（包括 Assert 失败且 AssertBehavior 为 AssertFatal 、 AssertPanic 时）后，
+	// 退出程序或panic前，输出崩溃信息
+	//
+	// 崩溃信息包含bininfo，最近的 CrashLastLines 条日志，以及所有协程的调用栈，
+	// 写入所有日志文件，以及 CrashFilename 文件。如果都没有，则写入stderr。
+	IsCrashDump    bool   `json:"is_crash_dump"`
+	CrashFilename  string `json:"crash_filename"`   // 崩溃信息文件，每次追加写入。为空时，如果 Filename 不为空，则使用 Filename +".crash"
+	CrashLastLines int    `json:"crash_last_lines"` // 崩溃信息中包含最近多少条日志，为0则不包含
 }
 
 // 没有配置的属性，将按如下配置
 var defaultOption = Option{
-	Level:               LevelDebug,
-	Filename:            "",
-	IsToStdout:          true,
-	IsRotateDaily:       false,
-	MaxRotateDays:       30,
-	ShortFileFlag:       true,
-	TimestampFlag:       true,
-	TimestampWithMsFlag: true,
-	LevelFlag:           true,
-	AssertBehavior:      AssertError,
+	Level:                 LevelDebug,
+	Filename:              "",
+	IsToStdout:            true,
+	IsRotateDaily:         false,
+	MaxRotateDays:         30,
+	MaxFileSizeMB:         0,
+	MaxBackups:            0,
+	IsCompressBackup:      false,
+	ShortFileFlag:         true,
+	TimestampFlag:         true,
+	TimestampWithMsFlag:   true,
+	LevelFlag:             true,
+	AssertBehavior:        AssertError,
+	Format:                FormatText,
+	IsAsync:               false,
+	AsyncBufferSize:       8192,
+	AsyncOverflowBehavior: AsyncOverflowBlock,
+	SampleIntervalMs:      0,
+	SampleFirst:           0,
+	SampleThereafter:      0,
+	IsCrashDump:           false,
+	CrashFilename:         "",
+	CrashLastLines:        100,
 }
 
 type Level uint8
@@ -199,6 +352,47 @@ func (a AssertBehavior) ReadableString() string {
 	}
 }
 
+type Format uint8
+
+const (
+	_            Format = iota
+	FormatText          // 1 文本格式，键值对以k=v的形式追加在日志内容之后
+	FormatJson          // 2 每行日志是一个json对象
+	FormatLogfmt        // 3 每行日志是空格分隔的k=v
+)
+
+func (f Format) ReadableString() string {
+	switch f {
+	case FormatText:
+		return "FormatText"
+	case FormatJson:
+		return "FormatJson"
+	case FormatLogfmt:
+		return "FormatLogfmt"
+	default:
+		return "unknown"
+	}
+}
+
+type AsyncOverflowBehavior uint8
+
+const (
+	_                  AsyncOverflowBehavior = iota
+	AsyncOverflowBlock                       // 1 阻塞等待缓冲区有空闲
+	AsyncOverflowDrop                        // 2 丢弃这条日志，并计数，见 Logger.GetDroppedNum 。注意，Fatal、Panic级别的日志不会被丢弃
+)
+
+func (a AsyncOverflowBehavior) ReadableString() string {
+	switch a {
+	case AsyncOverflowBlock:
+		return "AsyncOverflowBlock"
+	case AsyncOverflowDrop:
+		return "AsyncOverflowDrop"
+	default:
+		return "unknown"
+	}
+}
+
 type ModOption func(option *Option)
 
 func New(modOptions ...ModOption) (Logger, error) {
@@ -0,0 +1,170 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ParseLevel 解析日志级别，不区分大小写，支持"info"，"LevelInfo"，以及数字的形式
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	name = strings.TrimPrefix(name, "level")
+	switch name {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	case "fatal":
+		return LevelFatal, nil
+	case "panic":
+		return LevelPanic, nil
+	case "lognothing", "nothing":
+		return LevelLogNothing, nil
+	}
+	n, err := strconv.Atoi(name)
+	if err != nil || n < int(LevelTrace) || n > int(LevelLogNothing) {
+		return 0, ErrLog
+	}
+	return Level(n), nil
+}
+
+func (l *logger) SetLevel(level Level) error {
+	if level > LevelLogNothing {
+		return ErrLog
+	}
+	l.core.level.Store(uint32(level))
+	return nil
+}
+
+func (l *logger) SetPrefixLevel(prefix string, level Level) error {
+	if level > LevelLogNothing {
+		return ErrLog
+	}
+	l.core.prefixLevelsMu.Lock()
+	defer l.core.prefixLevelsMu.Unlock()
+	m := copyPrefixLevels(l.core.loadPrefixLevels())
+	m[prefix] = level
+	l.core.prefixLevels.Store(m)
+	return nil
+}
+
+func (l *logger) DeletePrefixLevel(prefix string) {
+	l.core.prefixLevelsMu.Lock()
+	defer l.core.prefixLevelsMu.Unlock()
+	m := copyPrefixLevels(l.core.loadPrefixLevels())
+	delete(m, prefix)
+	l.core.prefixLevels.Store(m)
+}
+
+func (l *logger) GetPrefixLevels() map[string]Level {
+	return copyPrefixLevels(l.core.loadPrefixLevels())
+}
+
+// isEnabled 级别为`level`的日志是否需要输出
+//
+// 如果该Logger的前缀有单独设置的日志级别，则使用最后添加的那个前缀对应的级别，否则使用全局的级别
+func (l *logger) isEnabled(level Level) bool {
+	minLevel := Level(l.core.level.Load())
+	if m := l.core.loadPrefixLevels(); len(m) != 0 {
+		for i := len(l.prefixs) - 1; i >= 0; i-- {
+			if v, ok := m[l.prefixs[i]]; ok {
+				minLevel = v
+				break
+			}
+		}
+	}
+	return level >= minLevel
+}
+
+// loadPrefixLevels 返回的map只读，修改时整体替换
+func (c *core) loadPrefixLevels() map[string]Level {
+	m, _ := c.prefixLevels.Load().(map[string]Level)
+	return m
+}
+
+func copyPrefixLevels(m map[string]Level) map[string]Level {
+	ret := make(map[string]Level, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
+
+// NewLevelHandler 通过http查看和修改`l`的日志级别
+//
+// GET          查看当前的日志级别
+// POST或PUT    修改日志级别，参数level为日志级别（格式见 ParseLevel ），如果带了参数prefix，则修改该前缀的日志级别
+// DELETE       删除参数prefix指定的前缀的日志级别设置
+//
+// 返回当前的日志级别，比如{"level":"LevelInfo","prefix_levels":{"rtmp":"LevelTrace"}}
+//
+// 比如 curl -X POST 'http://127.0.0.1:8080/debug/log/level?prefix=rtmp&level=trace'
+func NewLevelHandler(l Logger) http.Handler {
+	return &levelHandler{l: l}
+}
+
+type levelHandler struct {
+	l Logger
+}
+
+type levelHandlerResponse struct {
+	Level        string            `json:"level"`
+	PrefixLevels map[string]string `json:"prefix_levels"`
+}
+
+func (h *levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		level, err := ParseLevel(r.FormValue("level"))
+		if err != nil {
+			http.Error(w, "invalid level", http.StatusBadRequest)
+			return
+		}
+		if prefix := r.FormValue("prefix"); prefix != "" {
+			err = h.l.SetPrefixLevel(prefix, level)
+		} else {
+			err = h.l.SetLevel(level)
+		}
+		if err != nil {
+			http.Error(w, "invalid level", http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		prefix := r.FormValue("prefix")
+		if prefix == "" {
+			http.Error(w, "prefix required", http.StatusBadRequest)
+			return
+		}
+		h.l.DeletePrefixLevel(prefix)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := levelHandlerResponse{
+		Level:        h.l.GetOption().Level.ReadableString(),
+		PrefixLevels: make(map[string]string),
+	}
+	for prefix, level := range h.l.GetPrefixLevels() {
+		resp.PrefixLevels[prefix] = level.ReadableString()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,166 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func TestSetLevel(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Level = nazalog.LevelInfo
+		option.IsToStdout = false
+		option.ShortFileFlag = false
+		option.TimestampFlag = false
+		option.LevelFlag = false
+		option.PrefixLevels = map[string]nazalog.Level{"rtmp": nazalog.LevelTrace}
+		option.HookBackendOutFn = func(level nazalog.Level, line []byte) {
+			mu.Lock()
+			lines = append(lines, string(line))
+			mu.Unlock()
+		}
+	})
+	assert.Equal(t, nil, err)
+	rtmp := l.WithPrefix("rtmp")
+	conn := rtmp.WithPrefix("conn")
+	hls := l.WithPrefix("hls")
+
+	l.Debug("1")
+	rtmp.Trace("2")
+	conn.Trace("3")
+	hls.Debug("4")
+
+	// 最后添加的前缀优先
+	l.SetPrefixLevel("conn", nazalog.LevelError)
+	conn.Warn("5")
+	rtmp.Trace("6")
+
+	l.SetLevel(nazalog.LevelDebug)
+	l.DeletePrefixLevel("rtmp")
+	l.Debug("7")
+	rtmp.Trace("8")
+	hls.Debug("9")
+	assert.Equal(t, []string{"[rtmp] 2\n", "[rtmp] [conn] 3\n", "[rtmp] 6\n", "7\n", "[hls] 9\n"}, lines)
+
+	o := l.GetOption()
+	assert.Equal(t, nazalog.LevelDebug, o.Level)
+	assert.Equal(t, map[string]nazalog.Level{"conn": nazalog.LevelError}, o.PrefixLevels)
+
+	// 并发修改
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.SetLevel(nazalog.Level(j % 3))
+				l.SetPrefixLevel("rtmp", nazalog.Level(i))
+				rtmp.Trace("x")
+				l.Debug("x")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// 非法的日志级别不生效
+	assert.Equal(t, nil, l.SetLevel(nazalog.LevelWarn))
+	assert.Equal(t, nazalog.ErrLog, l.SetLevel(nazalog.LevelLogNothing+1))
+	assert.Equal(t, nazalog.LevelWarn, l.GetOption().Level)
+	assert.Equal(t, nazalog.ErrLog, l.SetPrefixLevel("hls", nazalog.Level(100)))
+	_, ok := l.GetPrefixLevels()["hls"]
+	assert.Equal(t, false, ok)
+
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.PrefixLevels = map[string]nazalog.Level{"rtmp": nazalog.LevelLogNothing + 1}
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+}
+
+func TestParseLevel(t *testing.T) {
+	golden := map[string]nazalog.Level{
+		"trace":           nazalog.LevelTrace,
+		"DEBUG":           nazalog.LevelDebug,
+		"LevelInfo":       nazalog.LevelInfo,
+		" warn ":          nazalog.LevelWarn,
+		"warning":         nazalog.LevelWarn,
+		"error":           nazalog.LevelError,
+		"fatal":           nazalog.LevelFatal,
+		"panic":           nazalog.LevelPanic,
+		"LevelLogNothing": nazalog.LevelLogNothing,
+		"2":               nazalog.LevelInfo,
+	}
+	for k, v := range golden {
+		level, err := nazalog.ParseLevel(k)
+		assert.Equal(t, nil, err, k)
+		assert.Equal(t, v, level, k)
+	}
+	for _, k := range []string{"", "x", "8", "-1"} {
+		_, err := nazalog.ParseLevel(k)
+		assert.Equal(t, nazalog.ErrLog, err, k)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Level = nazalog.LevelInfo
+	})
+	assert.Equal(t, nil, err)
+	h := nazalog.NewLevelHandler(l)
+
+	do := func(method string, target string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		var m map[string]interface{}
+		if w.Code == http.StatusOK {
+			assert.Equal(t, nil, json.Unmarshal(w.Body.Bytes(), &m))
+		}
+		return w.Code, m
+	}
+
+	code, m := do(http.MethodGet, "/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "LevelInfo", m["level"])
+
+	code, m = do(http.MethodPost, "/?level=debug")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "LevelDebug", m["level"])
+	assert.Equal(t, nazalog.LevelDebug, l.GetOption().Level)
+
+	code, m = do(http.MethodPut, "/?prefix=rtmp&level=trace")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"rtmp": "LevelTrace"}, m["prefix_levels"])
+
+	code, m = do(http.MethodDelete, "/?prefix=rtmp")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{}, m["prefix_levels"])
+
+	code, _ = do(http.MethodPost, "/?level=xxx")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodDelete, "/")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(http.MethodPatch, "/")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	// 表单参数
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("level=error"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h.ServeHTTP(w, req)
+	assert.Equal(t, nazalog.LevelError, l.GetOption().Level)
+}
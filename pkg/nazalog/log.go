@@ -10,14 +10,16 @@ package nazalog
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/q191201771/naza/pkg/mock"
+	"github.com/q191201771/naza/pkg/nazaatomic"
 
 	"github.com/q191201771/naza/pkg/nazacolor"
 
@@ -71,103 +73,191 @@ var (
 
 type logger struct {
 	prefixs []string
+	fields  []interface{} // 通过 With 添加的键值对
+	rule    *sampleRule   // 通过 LogEvery 、 LogFirstN 设置的采样规则，为nil时使用 Option 中配置的规则
 	core    *core
 }
 
 type core struct {
 	option Option
 
-	m             sync.Mutex
-	fp            *os.File
-	console       *os.File
-	buf           bytes.Buffer // TODO(chef): [refactor] 是否需要使用nazabytes.Buffer
-	currRoundTime time.Time
+	level          nazaatomic.Uint32 // 运行时可修改，初始值为 Option.Level
+	prefixLevels   atomic.Value      // map[string]Level ，运行时可修改，初始值为 Option.PrefixLevels
+	prefixLevelsMu sync.Mutex
+
+	m       sync.Mutex
+	sinks   []*sink
+	isColor bool          // 主格式是否使用彩色，输出至控制台时使用
+	line    formattedLine // TODO(chef): [refactor] 是否需要使用nazabytes.Buffer
+
+	bgMu      sync.Mutex
+	bgTasks   []backgroundTask // 翻滚后的压缩、清理等后台任务，由一个协程按顺序执行
+	bgRunning bool
+	bgDone    chan struct{} // 后台协程退出，也即所有后台任务完成时关闭，Sync 时等待
+
+	async *asyncWriter // 为nil时表示同步模式
+
+	sampleRule *sampleRule // Option 中配置的采样规则，为nil时表示不采样
+	sampler    sampler
+
+	crashSink *sink // 开启 Option.IsCrashDump 时保存最近的日志，也在 sinks 中，为nil表示不需要
+
+	backend backend // 不为nil时，日志不再格式化和输出至 sinks ，而是交给它处理，见 NewSlogLogger
+}
+
+// backend 替代 core 的格式化和输出
+type backend interface {
+	// handle 输出一条已经通过级别和采样过滤的日志
+	//
+	// @param ctx 通过 Tracec 等函数打印时为调用方传入的`ctx`，否则为 context.Background()
+	// @param pc  调用位置，为0时表示没有
+	handle(ctx context.Context, l *logger, level Level, now time.Time, pc uintptr, msg string, kv []interface{})
 }
 
 func (l *logger) Tracef(format string, v ...interface{}) {
-	l.Out(LevelTrace, 2, fmt.Sprintf(format, v...))
+	l.outf(LevelTrace, 2, format, v)
 }
 
 func (l *logger) Debugf(format string, v ...interface{}) {
-	l.Out(LevelDebug, 2, fmt.Sprintf(format, v...))
+	l.outf(LevelDebug, 2, format, v)
 }
 
 func (l *logger) Infof(format string, v ...interface{}) {
-	l.Out(LevelInfo, 2, fmt.Sprintf(format, v...))
+	l.outf(LevelInfo, 2, format, v)
 }
 
 func (l *logger) Warnf(format string, v ...interface{}) {
-	l.Out(LevelWarn, 2, fmt.Sprintf(format, v...))
+	l.outf(LevelWarn, 2, format, v)
 }
 
 func (l *logger) Errorf(format string, v ...interface{}) {
-	l.Out(LevelError, 2, fmt.Sprintf(format, v...))
+	l.outf(LevelError, 2, format, v)
 }
 
 func (l *logger) Fatalf(format string, v ...interface{}) {
-	l.Out(LevelFatal, 2, fmt.Sprintf(format, v...))
+	l.outf(LevelFatal, 2, format, v)
 	fake.Os_Exit(1)
 }
 
 func (l *logger) Panicf(format string, v ...interface{}) {
-	l.Out(LevelPanic, 2, fmt.Sprintf(format, v...))
+	l.outf(LevelPanic, 2, format, v)
 	panic(fmt.Sprintf(format, v...))
 }
 
 func (l *logger) Trace(v ...interface{}) {
-	l.Out(LevelTrace, 2, fmt.Sprint(v...))
+	l.out(LevelTrace, 2, fmt.Sprint(v...), nil)
 }
 
 func (l *logger) Debug(v ...interface{}) {
-	l.Out(LevelDebug, 2, fmt.Sprint(v...))
+	l.out(LevelDebug, 2, fmt.Sprint(v...), nil)
 }
 
 func (l *logger) Info(v ...interface{}) {
-	l.Out(LevelInfo, 2, fmt.Sprint(v...))
+	l.out(LevelInfo, 2, fmt.Sprint(v...), nil)
 }
 
 func (l *logger) Warn(v ...interface{}) {
-	l.Out(LevelWarn, 2, fmt.Sprint(v...))
+	l.out(LevelWarn, 2, fmt.Sprint(v...), nil)
 }
 
 func (l *logger) Error(v ...interface{}) {
-	l.Out(LevelError, 2, fmt.Sprint(v...))
+	l.out(LevelError, 2, fmt.Sprint(v...), nil)
 }
 
 func (l *logger) Fatal(v ...interface{}) {
-	l.Out(LevelFatal, 2, fmt.Sprint(v...))
+	l.out(LevelFatal, 2, fmt.Sprint(v...), nil)
 	fake.Os_Exit(1)
 }
 
 func (l *logger) Panic(v ...interface{}) {
-	l.Out(LevelPanic, 2, fmt.Sprint(v...))
+	l.out(LevelPanic, 2, fmt.Sprint(v...), nil)
 	panic(fmt.Sprint(v...))
 }
 
+func (l *logger) Tracew(msg string, kv ...interface{}) {
+	l.out(LevelTrace, 2, msg, kv)
+}
+
+func (l *logger) Debugw(msg string, kv ...interface{}) {
+	l.out(LevelDebug, 2, msg, kv)
+}
+
+func (l *logger) Infow(msg string, kv ...interface{}) {
+	l.out(LevelInfo, 2, msg, kv)
+}
+
+func (l *logger) Warnw(msg string, kv ...interface{}) {
+	l.out(LevelWarn, 2, msg, kv)
+}
+
+func (l *logger) Errorw(msg string, kv ...interface{}) {
+	l.out(LevelError, 2, msg, kv)
+}
+
+func (l *logger) Fatalw(msg string, kv ...interface{}) {
+	l.out(LevelFatal, 2, msg, kv)
+	fake.Os_Exit(1)
+}
+
+func (l *logger) Panicw(msg string, kv ...interface{}) {
+	l.out(LevelPanic, 2, msg, kv)
+	panic(msg)
+}
+
+func (l *logger) Tracec(ctx context.Context, msg string, kv ...interface{}) {
+	l.outc(ctx, LevelTrace, 2, msg, kv)
+}
+
+func (l *logger) Debugc(ctx context.Context, msg string, kv ...interface{}) {
+	l.outc(ctx, LevelDebug, 2, msg, kv)
+}
+
+func (l *logger) Infoc(ctx context.Context, msg string, kv ...interface{}) {
+	l.outc(ctx, LevelInfo, 2, msg, kv)
+}
+
+func (l *logger) Warnc(ctx context.Context, msg string, kv ...interface{}) {
+	l.outc(ctx, LevelWarn, 2, msg, kv)
+}
+
+func (l *logger) Errorc(ctx context.Context, msg string, kv ...interface{}) {
+	l.outc(ctx, LevelError, 2, msg, kv)
+}
+
+func (l *logger) Fatalc(ctx context.Context, msg string, kv ...interface{}) {
+	l.outc(ctx, LevelFatal, 2, msg, kv)
+	fake.Os_Exit(1)
+}
+
+func (l *logger) Panicc(ctx context.Context, msg string, kv ...interface{}) {
+	l.outc(ctx, LevelPanic, 2, msg, kv)
+	panic(msg)
+}
+
 func (l *logger) Output(calldepth int, s string) error {
-	l.Out(LevelInfo, calldepth, s)
+	l.out(LevelInfo, calldepth, s, nil)
 	return nil
 }
 
 func (l *logger) Print(v ...interface{}) {
-	l.Out(LevelInfo, 2, fmt.Sprint(v...))
+	l.out(LevelInfo, 2, fmt.Sprint(v...), nil)
 }
 
 func (l *logger) Printf(format string, v ...interface{}) {
-	l.Out(LevelInfo, 2, fmt.Sprintf(format, v...))
+	l.outf(LevelInfo, 2, format, v)
 }
 
 func (l *logger) Println(v ...interface{}) {
-	l.Out(LevelInfo, 2, fmt.Sprint(v...))
+	l.out(LevelInfo, 2, fmt.Sprint(v...), nil)
 }
 
 func (l *logger) Fatalln(v ...interface{}) {
-	l.Out(LevelInfo, 2, fmt.Sprint(v...))
+	l.out(LevelInfo, 2, fmt.Sprint(v...), nil)
 	fake.Os_Exit(1)
 }
 
 func (l *logger) Panicln(v ...interface{}) {
-	l.Out(LevelInfo, 2, fmt.Sprint(v...))
+	l.out(LevelInfo, 2, fmt.Sprint(v...), nil)
 	panic(fmt.Sprint(v...))
 }
 
@@ -181,147 +271,138 @@ func (l *logger) Assert(expected interface{}, actual interface{}, extInfo ...str
 		}
 		switch l.core.option.AssertBehavior {
 		case AssertError:
-			l.Out(LevelError, 2, v)
+			l.out(LevelError, 2, v, nil)
 		case AssertFatal:
-			l.Out(LevelFatal, 2, v)
+			l.out(LevelFatal, 2, v, nil)
 			fake.Os_Exit(1)
 		case AssertPanic:
-			l.Out(LevelPanic, 2, v)
+			l.out(LevelPanic, 2, v, nil)
 			panic(v)
 		}
 	}
 }
 
 func (l *logger) Out(level Level, calldepth int, s string) {
-	if l.core.option.Level > level {
-		return
-	}
-
-	now := Clock.Now()
-
-	var file string
-	var line int
-	if l.core.option.ShortFileFlag {
-		_, file, line, _ = runtime.Caller(calldepth)
+	l.out(level, calldepth+1, s, nil)
+}
 
-	}
+func (l *logger) Outf(level Level, calldepth int, format string, v ...interface{}) {
+	l.outf(level, calldepth+1, format, v)
+}
 
-	l.core.m.Lock()
+func (l *logger) Outw(level Level, calldepth int, msg string, kv ...interface{}) {
+	l.out(level, calldepth+1, msg, kv)
+}
 
-	l.core.buf.Reset()
+func (l *logger) Outc(ctx context.Context, level Level, calldepth int, msg string, kv ...interface{}) {
+	l.outc(ctx, level, calldepth+1, msg, kv)
+}
 
-	if l.core.option.TimestampFlag {
-		writeTime(&l.core.buf, now, l.core.option.TimestampWithMsFlag)
+// out
+//
+// @param kv 本条日志额外的键值对，和 With 添加的键值对一起输出
+func (l *logger) out(level Level, calldepth int, s string, kv []interface{}) {
+	if !l.allow(level, s, calldepth+1) {
+		return
 	}
+	l.output(context.Background(), level, calldepth+1, s, kv)
+}
 
-	l.writeLevelStringIfNeeded(level)
-
-	if l.prefixs != nil {
-		for _, s := range l.prefixs {
-			l.core.buf.WriteString("[")
-			l.core.buf.WriteString(s)
-			l.core.buf.WriteString("] ")
-		}
+// outf 和 out 的区别是，被过滤掉的日志不需要格式化，并且采样时使用`format`作为日志模板
+func (l *logger) outf(level Level, calldepth int, format string, v []interface{}) {
+	if !l.allow(level, format, calldepth+1) {
+		return
 	}
+	l.output(context.Background(), level, calldepth+1, fmt.Sprintf(format, v...), nil)
+}
 
-	l.core.buf.WriteString(s)
-
-	if file != "" && line > 0 {
-		l.core.buf.WriteString(" - ")
-
-		short := file
-		for i := len(file) - 1; i > 0; i-- {
-			if file[i] == '/' {
-				short = file[i+1:]
-				break
-			}
+// output 格式化并输出日志，调用前已经做过级别和采样的过滤
+//
+// @param ctx 只传递给 backend
+func (l *logger) output(ctx context.Context, level Level, calldepth int, s string, kv []interface{}) {
+	var pc uintptr
+	if l.core.option.ShortFileFlag {
+		var pcs [1]uintptr
+		// 注意，runtime.Callers 的`skip`为0时表示 runtime.Callers 自身，所以比 runtime.Caller 多1
+		if runtime.Callers(calldepth+1, pcs[:]) > 0 {
+			pc = pcs[0]
 		}
-		file = short
-
-		l.core.buf.WriteString(file)
-		l.core.buf.WriteByte(':')
-		itoa(&l.core.buf, line, -1)
 	}
+	now := Clock.Now()
+	l.outputAt(ctx, level, now, pc, s, kv)
 
-	if l.core.buf.Len() == 0 || l.core.buf.Bytes()[l.core.buf.Len()-1] != '\n' {
-		l.core.buf.WriteByte('\n')
+	if level >= LevelFatal && l.core.option.IsCrashDump {
+		l.core.writeCrashDump(level, now, s)
 	}
+}
 
-	// 输出至控制台
-	if l.core.console != nil {
-		_, _ = l.core.console.Write(l.core.buf.Bytes())
-		if level == LevelFatal || level == LevelPanic {
-			_ = l.core.console.Sync()
-		}
+// outputAt 和 output 的区别是，由调用方提供时间和调用位置
+//
+// @param pc 调用位置，为0时不输出源码文件及行号
+func (l *logger) outputAt(ctx context.Context, level Level, now time.Time, pc uintptr, s string, kv []interface{}) {
+	if l.core.backend != nil {
+		l.core.backend.handle(ctx, l, level, now, pc, s, kv)
+		return
 	}
 
-	// 输出至日志文件
-	if l.core.fp != nil {
-
-		// 同时满足条件时，翻滚一次就够了
-		rotateFlag := false
-		var backupName, expireName string
-
-		if l.core.option.IsRotateHourly && now.Hour() != l.core.currRoundTime.Hour() {
-			backupName = l.core.option.Filename + "." + l.core.currRoundTime.Format("2006010215")
-			expireName = l.core.option.Filename + "." + now.AddDate(0, 0, -l.core.option.MaxRotateDays).Format("2006010215")
-			rotateFlag = true
-		}
+	var file string
+	var line int
+	if l.core.option.ShortFileFlag && pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		file, line = frame.File, frame.Line
+	}
 
-		if !rotateFlag && l.core.option.IsRotateDaily && now.Day() != l.core.currRoundTime.Day() {
-			backupName = l.core.option.Filename + "." + l.core.currRoundTime.Format("20060102")
-			expireName = l.core.option.Filename + "." + now.AddDate(0, 0, -l.core.option.MaxRotateDays).Format("20060102")
-			rotateFlag = true
+	// 异步模式，格式化后放入缓冲区，由异步协程负责输出
+	if l.core.async != nil {
+		entry := l.core.async.acquireEntry()
+		l.formatLine(&entry.line, level, now, file, line, s, kv)
+		entry.level = level
+		entry.t = now
+		if l.core.async.push(entry) {
+			if level == LevelFatal || level == LevelPanic {
+				l.Sync()
+			}
+			return
 		}
+		// 异步协程已退出，退化为同步输出
+		l.core.m.Lock()
+		l.core.writeWithLock(level, now, &entry.line)
+		l.core.m.Unlock()
+		l.core.async.releaseEntry(entry)
+		return
+	}
 
-		if rotateFlag {
-			err := l.core.fp.Close()
-			// 忽略关闭的错误
-			_ = err
+	l.core.m.Lock()
 
-			err = os.Rename(l.core.option.Filename, backupName)
-			os.Remove(expireName)
-			if err != nil {
-				// windows会走这个逻辑分支
-				// TODO(chef): 应判断具体的错误值 202302
+	l.core.line.reset()
+	l.formatLine(&l.core.line, level, now, file, line, s, kv)
+	l.core.writeWithLock(level, now, &l.core.line)
 
-				l.core.fp, err = os.OpenFile(l.core.option.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-			} else {
-				l.core.fp, err = os.Create(l.core.option.Filename)
-			}
-			if err != nil {
-				_, _ = fmt.Fprintf(os.Stderr, "reopen error. err=%+v, fp=%+v, filename=%s, backupName=%s, now=%s, curr=%s",
-					err, l.core.fp, l.core.option.Filename, backupName, now.String(), l.core.currRoundTime.String())
-				return
-			}
+	l.core.m.Unlock()
+}
 
-			l.core.currRoundTime = now
-		}
+func (l *logger) Sync() {
+	l.flushSampled(1)
 
-		_, _ = l.core.fp.Write(l.core.buf.Bytes())
-		if level == LevelFatal || level == LevelPanic {
-			_ = l.core.fp.Sync()
-		}
+	if l.core.async != nil {
+		l.core.async.flush()
 	}
 
-	// 输出至hook
-	if l.core.option.HookBackendOutFn != nil {
-		l.core.option.HookBackendOutFn(level, l.core.buf.Bytes())
+	l.core.m.Lock()
+	for _, s := range l.core.sinks {
+		s.sync()
 	}
-
 	l.core.m.Unlock()
-}
 
-func (l *logger) Sync() {
-	l.core.m.Lock()
-	defer l.core.m.Unlock()
+	// 在锁外等待后台的压缩和清理任务，避免阻塞其他协程写日志
+	l.core.waitBackground()
+}
 
-	if l.core.console != nil {
-		_ = l.core.console.Sync()
-	}
-	if l.core.fp != nil {
-		_ = l.core.fp.Sync()
+func (l *logger) GetDroppedNum() uint64 {
+	if l.core.async == nil {
+		return 0
 	}
+	return l.core.async.droppedNum.Load()
 }
 
 func (l *logger) WithPrefix(s string) Logger {
@@ -333,47 +414,159 @@ func (l *logger) WithPrefix(s string) Logger {
 	prefixs = append(prefixs, s)
 	ll := &logger{
 		prefixs: prefixs,
+		fields:  l.fields,
+		rule:    l.rule,
+		core:    l.core,
+	}
+	return ll
+}
+
+func (l *logger) With(kv ...interface{}) Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+	ll := &logger{
+		prefixs: l.prefixs,
+		fields:  fields,
+		rule:    l.rule,
 		core:    l.core,
 	}
 	return ll
 }
 
 func (l *logger) GetOption() Option {
-	return l.core.option
+	option := l.core.option
+	option.Level = Level(l.core.level.Load())
+	option.PrefixLevels = l.GetPrefixLevels()
+	return option
 }
 
 func (l *logger) Init(modOptions ...ModOption) error {
 	var err error
 
-	l.core.currRoundTime = time.Now()
+	// 先把之前异步缓冲区中的日志输出完
+	if l.core.async != nil {
+		l.core.async.close()
+		l.core.async = nil
+	}
+
 	l.core.option = defaultOption
 
 	for _, fn := range modOptions {
 		fn(&l.core.option)
 	}
+	// 调用方整体覆盖 Option 时（比如从没有format字段的配置文件中加载），Format 可能为0
+	if l.core.option.Format == 0 {
+		l.core.option.Format = FormatText
+	}
 
 	if err = validate(l.core.option); err != nil {
 		return err
 	}
-	if l.core.option.Filename != "" {
-		dir := filepath.Dir(l.core.option.Filename)
-		if err = os.MkdirAll(dir, 0777); err != nil {
-			return err
+	l.core.level.Store(uint32(l.core.option.Level))
+	l.core.prefixLevels.Store(copyPrefixLevels(l.core.option.PrefixLevels))
+
+	sinks, err := newSinks(l.core, l.core.option)
+	if err != nil {
+		return err
+	}
+	for _, s := range l.core.sinks {
+		s.close()
+	}
+	l.core.crashSink = newCrashSink(l.core.option)
+	if l.core.crashSink != nil {
+		sinks = append(sinks, l.core.crashSink)
+	}
+	l.core.sinks = sinks
+	l.core.isColor = l.core.option.IsToStdout
+	l.core.sampleRule = newSampleRule(l.core.option)
+	l.core.sampler.reset()
+	if l.core.option.IsAsync {
+		l.core.async = newAsyncWriter(l.core)
+	}
+
+	return nil
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// formatLine 按所有输出目标需要的格式格式化一行日志
+func (l *logger) formatLine(fl *formattedLine, level Level, now time.Time, file string, line int, s string, kv []interface{}) {
+	fl.prefixs = l.prefixs
+	l.format(&fl.main, l.core.option.Format, l.core.isColor, level, now, file, line, s, kv)
+	for _, sk := range l.core.sinks {
+		if sk.format == 0 || level < sk.level || fl.hasBufs[sk.format-1] {
+			continue
 		}
-		if l.core.fp, err = os.OpenFile(l.core.option.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666); err != nil {
-			return err
+		l.format(&fl.bufs[sk.format-1], sk.format, false, level, now, file, line, s, kv)
+		fl.hasBufs[sk.format-1] = true
+	}
+}
+
+// format 将一行日志按`f`格式化后写入`buf`
+//
+// @param isColor 文本格式下，日志级别字段是否使用彩色
+func (l *logger) format(buf *bytes.Buffer, f Format, isColor bool, level Level, now time.Time, file string, line int, s string, kv []interface{}) {
+	switch f {
+	case FormatJson:
+		l.formatJson(buf, level, now, file, line, s, kv)
+		return
+	case FormatLogfmt:
+		l.formatLogfmt(buf, level, now, file, line, s, kv)
+		return
+	}
+
+	if l.core.option.TimestampFlag {
+		writeTime(buf, now, l.core.option.TimestampWithMsFlag)
+	}
+
+	l.writeLevelStringIfNeeded(buf, level, isColor)
+
+	if l.prefixs != nil {
+		for _, s := range l.prefixs {
+			buf.WriteString("[")
+			buf.WriteString(s)
+			buf.WriteString("] ")
 		}
 	}
-	if l.core.option.IsToStdout {
-		l.core.console = os.Stdout
+
+	if len(l.fields) == 0 && len(kv) == 0 {
+		buf.WriteString(s)
 	} else {
-		l.core.console = nil
+		buf.WriteString(strings.TrimRight(s, "\n"))
+		rangeFields(l.fields, kv, func(key string, value interface{}) {
+			buf.WriteByte(' ')
+			writeLogfmtField(buf, key, value)
+		})
 	}
 
-	return nil
+	if file != "" && line > 0 {
+		buf.WriteString(" - ")
+		buf.WriteString(shortFile(file))
+		buf.WriteByte(':')
+		itoa(buf, line, -1)
+	}
+
+	if buf.Len() == 0 || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
 }
 
-// ---------------------------------------------------------------------------------------------------------------------
+// writeWithLock 输出一行日志至各输出目标以及hook
+//
+// 注意，调用方需持有 core.m
+func (c *core) writeWithLock(level Level, now time.Time, fl *formattedLine) {
+	for _, s := range c.sinks {
+		if level >= s.level {
+			s.write(level, now, fl)
+		}
+	}
+
+	// 输出至hook
+	if c.option.HookBackendOutFn != nil {
+		c.option.HookBackendOutFn(level, fl.main.Bytes())
+	}
+}
 
 func newLogger(modOptions ...ModOption) (*logger, error) {
 	l := &logger{
@@ -389,9 +582,52 @@ func validate(option Option) error {
 	if option.Level < LevelTrace || option.Level > LevelLogNothing {
 		return ErrLog
 	}
+	for _, level := range option.PrefixLevels {
+		if level > LevelLogNothing {
+			return ErrLog
+		}
+	}
 	if option.AssertBehavior < AssertError || option.AssertBehavior > AssertPanic {
 		return ErrLog
 	}
+	if option.MaxRotateDays < 0 || option.MaxFileSizeMB < 0 || option.MaxBackups < 0 {
+		return ErrLog
+	}
+	if option.Format > FormatLogfmt {
+		return ErrLog
+	}
+	for _, so := range option.Sinks {
+		if so.Level > LevelLogNothing || so.Format > FormatLogfmt {
+			return ErrLog
+		}
+		n := 0
+		if so.Filename != "" {
+			n++
+		}
+		if so.Writer != nil {
+			n++
+		}
+		if so.Memory != nil {
+			n++
+		}
+		if n != 1 {
+			return ErrLog
+		}
+	}
+	if option.CrashLastLines < 0 {
+		return ErrLog
+	}
+	if option.SampleIntervalMs < 0 || option.SampleFirst < 0 || option.SampleThereafter < 0 {
+		return ErrLog
+	}
+	if option.IsAsync {
+		if option.AsyncBufferSize <= 0 {
+			return ErrLog
+		}
+		if option.AsyncOverflowBehavior < AsyncOverflowBlock || option.AsyncOverflowBehavior > AsyncOverflowDrop {
+			return ErrLog
+		}
+	}
 	return nil
 }
 
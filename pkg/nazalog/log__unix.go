@@ -11,12 +11,14 @@
 
 package nazalog
 
-func (l *logger) writeLevelStringIfNeeded(level Level) {
+import "bytes"
+
+func (l *logger) writeLevelStringIfNeeded(buf *bytes.Buffer, level Level, isColor bool) {
 	if l.core.option.LevelFlag {
-		if l.core.console != nil {
-			l.core.buf.WriteString(levelToColorString[level])
+		if isColor {
+			buf.WriteString(levelToColorString[level])
 		} else {
-			l.core.buf.WriteString(levelToString[level])
+			buf.WriteString(levelToString[level])
 		}
 	}
 }
@@ -11,9 +11,11 @@
 
 package nazalog
 
-func (l *logger) writeLevelStringIfNeeded(level Level) {
+import "bytes"
+
+func (l *logger) writeLevelStringIfNeeded(buf *bytes.Buffer, level Level, isColor bool) {
 	if l.core.option.LevelFlag {
 		// windows系统不用写带颜色的日志级别字段
-		l.core.buf.WriteString(levelToString[level])
+		buf.WriteString(levelToString[level])
 	}
 }
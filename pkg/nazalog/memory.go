@@ -0,0 +1,253 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type MemorySinkOption struct {
+	MaxLines int // 最多保存的日志条数，为0则不按条数限制
+	MaxBytes int // 最多保存的日志字节数，为0则不按字节数限制。注意，单条日志超过该大小时依然会保存这一条
+}
+
+var defaultMemorySinkOption = MemorySinkOption{
+	MaxLines: 1000,
+	MaxBytes: 0,
+}
+
+type ModMemorySinkOption func(option *MemorySinkOption)
+
+// MemoryRecord MemorySink 中保存的一条日志
+type MemoryRecord struct {
+	Level   Level
+	Time    time.Time
+	Prefixs []string // 打印该日志的Logger对象的前缀
+	Line    string   // 按 SinkOption.Format 格式化后的日志，不包含尾部的换行符
+}
+
+// MemoryQuery 查询条件，零值的条件不生效
+type MemoryQuery struct {
+	Level    Level     // 大于等于该级别
+	Prefix   string    // Logger对象的前缀中包含该前缀
+	Since    time.Time // 时间大于等于Since
+	Until    time.Time // 时间小于Until
+	Contains string    // 日志内容包含该子串
+	Limit    int       // 最多返回最新的多少条
+}
+
+// MemorySink 在内存中保存最近的日志，超出 MemorySinkOption 的限制时丢弃最老的，用于在管理页面等场景下查看最近的日志
+//
+// 通过 SinkOption.Memory 添加为日志输出目标，可以和其他函数并发调用
+type MemorySink struct {
+	option MemorySinkOption
+
+	mu      sync.Mutex
+	records []MemoryRecord // 从老到新
+	bytes   int
+}
+
+func NewMemorySink(modOptions ...ModMemorySinkOption) *MemorySink {
+	option := defaultMemorySinkOption
+	for _, fn := range modOptions {
+		fn(&option)
+	}
+	return &MemorySink{
+		option: option,
+	}
+}
+
+// Query 按时间从老到新的顺序，返回满足条件的日志
+func (m *MemorySink) Query(q MemoryQuery) []MemoryRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 从新到老查找，使得 Limit 保留的是最新的
+	var ret []MemoryRecord
+	for i := len(m.records) - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(ret) == q.Limit {
+			break
+		}
+		if q.match(&m.records[i]) {
+			ret = append(ret, m.records[i])
+		}
+	}
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret
+}
+
+// Len 当前保存的日志条数
+func (m *MemorySink) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.records)
+}
+
+// Reset 清空保存的日志
+func (m *MemorySink) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = nil
+	m.bytes = 0
+}
+
+func (m *MemorySink) add(level Level, now time.Time, prefixs []string, b []byte) {
+	line := string(b)
+	if strings.HasSuffix(line, "\n") {
+		line = line[:len(line)-1]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, MemoryRecord{
+		Level:   level,
+		Time:    now,
+		Prefixs: prefixs,
+		Line:    line,
+	})
+	m.bytes += len(line)
+
+	n := 0
+	for n < len(m.records)-1 &&
+		((m.option.MaxLines > 0 && len(m.records)-n > m.option.MaxLines) ||
+			(m.option.MaxBytes > 0 && m.bytes > m.option.MaxBytes)) {
+		m.bytes -= len(m.records[n].Line)
+		m.records[n] = MemoryRecord{}
+		n++
+	}
+	m.records = m.records[n:]
+}
+
+func (q *MemoryQuery) match(r *MemoryRecord) bool {
+	if r.Level < q.Level {
+		return false
+	}
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.Time.Before(q.Until) {
+		return false
+	}
+	if q.Prefix != "" {
+		found := false
+		for _, prefix := range r.Prefixs {
+			if prefix == q.Prefix {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return q.Contains == "" || strings.Contains(r.Line, q.Contains)
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+// NewMemorySinkHandler 以JSON格式提供`m`中的日志，只支持GET
+//
+// 查询参数对应 MemoryQuery ，都是可选的：
+//
+//	level    最低日志级别，格式见 ParseLevel ，比如"warn"
+//	prefix   前缀
+//	since    开始时间（包含），RFC3339格式或者unix毫秒时间戳
+//	until    结束时间（不包含），格式同since
+//	contains 日志内容包含的子串
+//	limit    最多返回最新的多少条
+//
+// 比如 GET /debug/log/recent?level=warn&prefix=rtmp&limit=100
+//
+// 响应的格式为 {"records":[{"time":"2026-10-18T12:00:00.000000+08:00","level":"WARN","prefix":["rtmp"],"line":"..."}]}
+func NewMemorySinkHandler(m *MemorySink) http.Handler {
+	return &memorySinkHandler{m: m}
+}
+
+type memorySinkHandler struct {
+	m *MemorySink
+}
+
+type memoryRecordResp struct {
+	Time   string   `json:"time"`
+	Level  string   `json:"level"`
+	Prefix []string `json:"prefix"`
+	Line   string   `json:"line"`
+}
+
+type memorySinkResp struct {
+	Records []memoryRecordResp `json:"records"`
+}
+
+func (h *memorySinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q, err := parseMemoryQuery(r)
+	if err != nil {
+		http.Error(w, "invalid query", http.StatusBadRequest)
+		return
+	}
+
+	records := h.m.Query(q)
+	resp := memorySinkResp{
+		Records: make([]memoryRecordResp, 0, len(records)),
+	}
+	for _, record := range records {
+		resp.Records = append(resp.Records, memoryRecordResp{
+			Time:   formatTimestamp(record.Time, true),
+			Level:  levelName(record.Level),
+			Prefix: record.Prefixs,
+			Line:   record.Line,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func parseMemoryQuery(r *http.Request) (q MemoryQuery, err error) {
+	values := r.URL.Query()
+	if s := values.Get("level"); s != "" {
+		if q.Level, err = ParseLevel(s); err != nil {
+			return q, err
+		}
+	}
+	if s := values.Get("since"); s != "" {
+		if q.Since, err = parseQueryTime(s); err != nil {
+			return q, err
+		}
+	}
+	if s := values.Get("until"); s != "" {
+		if q.Until, err = parseQueryTime(s); err != nil {
+			return q, err
+		}
+	}
+	if s := values.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil || q.Limit < 0 {
+			return q, ErrLog
+		}
+	}
+	q.Prefix = values.Get("prefix")
+	q.Contains = values.Get("contains")
+	return q, nil
+}
+
+func parseQueryTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
@@ -0,0 +1,156 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/mock"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func TestMemorySink(t *testing.T) {
+	nazalog.Clock = mock.NewFakeClock()
+	defer func() {
+		nazalog.Clock = mock.NewStdClock()
+	}()
+	start := time.Unix(1600000000, 0)
+
+	for _, isAsync := range []bool{false, true} {
+		nazalog.Clock.Set(start)
+		m := nazalog.NewMemorySink(func(option *nazalog.MemorySinkOption) {
+			option.MaxLines = 4
+		})
+		l, err := nazalog.New(func(option *nazalog.Option) {
+			option.IsToStdout = false
+			option.TimestampFlag = false
+			option.ShortFileFlag = false
+			option.IsAsync = isAsync
+			option.Sinks = []nazalog.SinkOption{{Level: nazalog.LevelInfo, Memory: m}}
+		})
+		assert.Equal(t, nil, err)
+
+		l.Info("dropped by MaxLines")
+		l.Debug("dropped by level")
+		for i := 0; i < 4; i++ {
+			nazalog.Clock.Add(time.Second)
+			l.WithPrefix("rtmp").Infof("conn %d", i)
+		}
+		l.WithPrefix("rtsp").Warn("conn 4")
+		l.Sync()
+		assert.Equal(t, 4, m.Len())
+
+		records := m.Query(nazalog.MemoryQuery{})
+		assert.Equal(t, 4, len(records))
+		assert.Equal(t, " INFO [rtmp] conn 1", records[0].Line)
+		assert.Equal(t, []string{"rtmp"}, records[0].Prefixs)
+		assert.Equal(t, start.Add(2*time.Second), records[0].Time)
+		assert.Equal(t, nazalog.LevelWarn, records[3].Level)
+
+		records = m.Query(nazalog.MemoryQuery{Prefix: "rtmp", Limit: 2})
+		assert.Equal(t, 2, len(records))
+		assert.Equal(t, " INFO [rtmp] conn 2", records[0].Line)
+		assert.Equal(t, " INFO [rtmp] conn 3", records[1].Line)
+
+		records = m.Query(nazalog.MemoryQuery{Level: nazalog.LevelWarn})
+		assert.Equal(t, 1, len(records))
+		assert.Equal(t, " WARN [rtsp] conn 4", records[0].Line)
+
+		records = m.Query(nazalog.MemoryQuery{Since: start.Add(2 * time.Second), Until: start.Add(4 * time.Second), Contains: "conn"})
+		assert.Equal(t, 2, len(records))
+		assert.Equal(t, " INFO [rtmp] conn 2", records[1].Line)
+
+		m.Reset()
+		assert.Equal(t, 0, m.Len())
+	}
+}
+
+func TestMemorySink_MaxBytes(t *testing.T) {
+	m := nazalog.NewMemorySink(func(option *nazalog.MemorySinkOption) {
+		option.MaxLines = 0
+		option.MaxBytes = 10
+	})
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.IsToStdout = false
+		option.TimestampFlag = false
+		option.ShortFileFlag = false
+		option.LevelFlag = false
+		option.Sinks = []nazalog.SinkOption{{Memory: m}}
+	})
+	assert.Equal(t, nil, err)
+
+	l.Info("aaaa")
+	l.Info("bbbb")
+	l.Info("cccc")
+	records := m.Query(nazalog.MemoryQuery{})
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "bbbb", records[0].Line)
+
+	// 单条日志超过限制时依然保存这一条
+	l.Info("dddddddddddd")
+	records = m.Query(nazalog.MemoryQuery{})
+	assert.Equal(t, 1, len(records))
+
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.Sinks = []nazalog.SinkOption{{Memory: m, Filename: "/tmp/a.log"}}
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+}
+
+func TestMemorySinkHandler(t *testing.T) {
+	m := nazalog.NewMemorySink()
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.IsToStdout = false
+		option.Sinks = []nazalog.SinkOption{{Memory: m, Format: nazalog.FormatJson}}
+	})
+	assert.Equal(t, nil, err)
+	l.WithPrefix("rtmp").Info("a")
+	l.Error("b")
+	l.Error("c")
+
+	h := nazalog.NewMemorySinkHandler(m)
+	since := strconv.FormatInt(time.Now().Add(-time.Minute).UnixNano()/int64(time.Millisecond), 10)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=error&limit=1&since="+since, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Records []struct {
+			Time   string   `json:"time"`
+			Level  string   `json:"level"`
+			Prefix []string `json:"prefix"`
+			Line   string   `json:"line"`
+		} `json:"records"`
+	}
+	assert.Equal(t, nil, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1, len(resp.Records))
+	assert.Equal(t, "ERROR", resp.Records[0].Level)
+	var line map[string]interface{}
+	assert.Equal(t, nil, json.Unmarshal([]byte(resp.Records[0].Line), &line))
+	assert.Equal(t, "c", line["msg"])
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?prefix=rtmp", nil))
+	assert.Equal(t, nil, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1, len(resp.Records))
+	assert.Equal(t, []string{"rtmp"}, resp.Records[0].Prefix)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?until=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,345 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const compressSuffix = ".gz"
+
+// rotateFile 支持翻滚的日志文件
+type rotateFile struct {
+	core   *core
+	option Option // 使用其中翻滚相关的配置，Filename 为该文件的文件名
+
+	fp            *os.File
+	fileSize      int64 // 当前日志文件的大小
+	currRoundTime time.Time
+}
+
+// openRotateFile 打开日志文件，目录不存在则自动创建，如果开启了翻滚，则在后台清理之前遗留的过期备份文件
+func openRotateFile(c *core, option Option) (*rotateFile, error) {
+	dir := filepath.Dir(option.Filename)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	fp, err := os.OpenFile(option.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	f := &rotateFile{
+		core:          c,
+		option:        option,
+		fp:            fp,
+		currRoundTime: Clock.Now(),
+	}
+	if fi, err := fp.Stat(); err == nil {
+		f.fileSize = fi.Size()
+	}
+	if isRotateEnabled(option) {
+		c.runBackground(option, "")
+	}
+	return f, nil
+}
+
+// isRotateEnabled 是否开启了翻滚，没有开启时不清理备份文件，避免误删其他程序产生的同名文件
+func isRotateEnabled(option Option) bool {
+	return option.IsRotateDaily || option.IsRotateHourly || option.MaxFileSizeMB > 0
+}
+
+// write 写入前，如果需要，先翻滚日志文件
+//
+// 注意，调用方需持有 core.m
+func (f *rotateFile) write(now time.Time, b []byte) error {
+	if f.fp == nil {
+		return os.ErrInvalid
+	}
+	if err := f.rotateIfNeeded(now, len(b)); err != nil {
+		return err
+	}
+	n, err := f.fp.Write(b)
+	f.fileSize += int64(n)
+	return err
+}
+
+func (f *rotateFile) sync() {
+	if f.fp != nil {
+		_ = f.fp.Sync()
+	}
+}
+
+func (f *rotateFile) close() {
+	if f.fp != nil {
+		_ = f.fp.Close()
+		f.fp = nil
+	}
+}
+
+// needRotate 写入`n`字节前，是否需要翻滚日志文件
+//
+// @param pending 在这`n`字节之前，还有多少字节已经确定要写入当前文件但是还没有写
+func (f *rotateFile) needRotate(now time.Time, pending int, n int) bool {
+	_, rotateFlag := f.rotateName(now, pending, n)
+	return rotateFlag
+}
+
+// rotateName
+//
+// @param n 即将写入的字节数
+//
+// @return rotateFlag 为true时表示`now`已经进入新的翻滚周期，或者写入后文件大小将超过 Option.MaxFileSizeMB
+func (f *rotateFile) rotateName(now time.Time, pending int, n int) (backupName string, rotateFlag bool) {
+	// 同时满足条件时，翻滚一次就够了
+	if f.option.IsRotateHourly && now.Hour() != f.currRoundTime.Hour() {
+		return f.option.Filename + "." + f.currRoundTime.Format("2006010215"), true
+	}
+
+	if f.option.IsRotateDaily && now.Day() != f.currRoundTime.Day() {
+		return f.option.Filename + "." + f.currRoundTime.Format("20060102"), true
+	}
+
+	// 文件为空时不翻滚，避免单条日志超过大小限制时不停翻滚
+	size := f.fileSize + int64(pending)
+	if f.option.MaxFileSizeMB > 0 && size > 0 && size+int64(n) > int64(f.option.MaxFileSizeMB)*1024*1024 {
+		return f.option.Filename + "." + now.Format("20060102150405"), true
+	}
+
+	return "", false
+}
+
+// rotateIfNeeded 如果需要，则翻滚日志文件，见 rotateName
+//
+// @param n 即将写入的字节数
+//
+// @return 重新打开日志文件失败时返回错误
+func (f *rotateFile) rotateIfNeeded(now time.Time, n int) error {
+	backupName, rotateFlag := f.rotateName(now, 0, n)
+	if !rotateFlag {
+		return nil
+	}
+	backupName = uniqueBackupName(backupName)
+
+	err := f.fp.Close()
+	// 忽略关闭的错误
+	_ = err
+
+	err = os.Rename(f.option.Filename, backupName)
+	renamed := err == nil
+	if err != nil {
+		// windows会走这个逻辑分支
+		// TODO(chef): 应判断具体的错误值 202302
+
+		f.fp, err = os.OpenFile(f.option.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	} else {
+		f.fp, err = os.Create(f.option.Filename)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "reopen error. err=%+v, fp=%+v, filename=%s, backupName=%s, now=%s, curr=%s",
+			err, f.fp, f.option.Filename, backupName, now.String(), f.currRoundTime.String())
+		f.fp = nil
+		return err
+	}
+
+	f.currRoundTime = now
+	f.fileSize = 0
+	if fi, err := f.fp.Stat(); err == nil {
+		f.fileSize = fi.Size()
+	}
+
+	if renamed {
+		f.core.runBackground(f.option, backupName)
+	}
+	return nil
+}
+
+// backgroundTask 翻滚后需要在后台执行的任务
+type backgroundTask struct {
+	backupName string // 刚翻滚出的备份文件，为空则只做清理
+	option     Option
+	now        time.Time
+}
+
+// runBackground 在后台协程中压缩刚翻滚出的备份文件（如果开启了 Option.IsCompressBackup ），并清理过期的备份文件
+//
+// 任务按添加的顺序串行执行
+//
+// @param option     日志文件的配置
+// @param backupName 刚翻滚出的备份文件，为空则只做清理
+func (c *core) runBackground(option Option, backupName string) {
+	c.bgMu.Lock()
+	defer c.bgMu.Unlock()
+	c.bgTasks = append(c.bgTasks, backgroundTask{
+		backupName: backupName,
+		option:     option,
+		now:        Clock.Now(),
+	})
+	if !c.bgRunning {
+		c.bgRunning = true
+		c.bgDone = make(chan struct{})
+		go c.runBackgroundLoop()
+	}
+}
+
+// waitBackground 等待之前添加的后台任务全部完成
+func (c *core) waitBackground() {
+	c.bgMu.Lock()
+	if !c.bgRunning {
+		c.bgMu.Unlock()
+		return
+	}
+	done := c.bgDone
+	c.bgMu.Unlock()
+	<-done
+}
+
+func (c *core) runBackgroundLoop() {
+	for {
+		c.bgMu.Lock()
+		if len(c.bgTasks) == 0 {
+			c.bgRunning = false
+			close(c.bgDone)
+			c.bgMu.Unlock()
+			return
+		}
+		task := c.bgTasks[0]
+		c.bgTasks = c.bgTasks[1:]
+		c.bgMu.Unlock()
+
+		if task.backupName != "" && task.option.IsCompressBackup {
+			if err := compressFile(task.backupName); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "compress log backup error. err=%+v, backupName=%s", err, task.backupName)
+			}
+		}
+		sweepBackups(task.option, task.now)
+	}
+}
+
+// sweepBackups 删除超过 Option.MaxRotateDays 天，或者超出 Option.MaxBackups 个数的备份文件
+//
+// 备份文件的新旧由文件的修改时间决定
+func sweepBackups(option Option, now time.Time) {
+	if option.MaxRotateDays <= 0 && option.MaxBackups <= 0 {
+		return
+	}
+
+	dir, base := filepath.Split(option.Filename)
+	if dir == "" {
+		dir = "."
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var backups []os.FileInfo
+	for _, fi := range infos {
+		if !fi.IsDir() && isBackupName(fi.Name(), base) {
+			backups = append(backups, fi)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].ModTime().Equal(backups[j].ModTime()) {
+			return backups[i].Name() > backups[j].Name()
+		}
+		return backups[i].ModTime().After(backups[j].ModTime())
+	})
+
+	expire := now.AddDate(0, 0, -option.MaxRotateDays)
+	for i, fi := range backups {
+		if (option.MaxBackups > 0 && i >= option.MaxBackups) ||
+			(option.MaxRotateDays > 0 && fi.ModTime().Before(expire)) {
+			_ = os.Remove(filepath.Join(dir, fi.Name()))
+		}
+	}
+}
+
+// isBackupName `name`是否是日志文件`base`翻滚出的备份文件，比如"app.log.20260102"，"app.log.20260102150405.1.gz"
+func isBackupName(name string, base string) bool {
+	if !strings.HasPrefix(name, base+".") {
+		return false
+	}
+	suffix := strings.TrimSuffix(name[len(base)+1:], compressSuffix)
+	if suffix == "" || suffix[0] < '0' || suffix[0] > '9' {
+		return false
+	}
+	for i := 0; i < len(suffix); i++ {
+		if (suffix[i] < '0' || suffix[i] > '9') && suffix[i] != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueBackupName 如果`name`或者其压缩后的文件已经存在，则在尾部添加序号，避免覆盖之前的备份文件
+func uniqueBackupName(name string) string {
+	candidate := name
+	for i := 1; ; i++ {
+		if !isExist(candidate) && !isExist(candidate+compressSuffix) {
+			return candidate
+		}
+		candidate = name + "." + strconv.Itoa(i)
+	}
+}
+
+func isExist(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// compressFile 将`name`压缩为`name`.gz，并删除`name`
+//
+// 压缩后的文件保留原文件的修改时间，使得清理时依然按原文件的新旧判断
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dstName := name + compressSuffix
+	dst, err := os.OpenFile(dstName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dstName)
+		}
+	}()
+
+	gw := gzip.NewWriter(dst)
+	if _, err = io.Copy(gw, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gw.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = os.Chtimes(dstName, fi.ModTime(), fi.ModTime())
+
+	_ = src.Close()
+	return os.Remove(name)
+}
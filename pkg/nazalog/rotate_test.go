@@ -0,0 +1,203 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/mock"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func TestRotate_Size(t *testing.T) {
+	for _, isAsync := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "nazalogtest")
+		assert.Equal(t, nil, err)
+		filename := filepath.Join(dir, "size.log")
+
+		l, err := nazalog.New(func(option *nazalog.Option) {
+			option.Filename = filename
+			option.IsToStdout = false
+			option.MaxFileSizeMB = 1
+			option.MaxBackups = 2
+			option.IsCompressBackup = true
+			option.IsAsync = isAsync
+		})
+		assert.Equal(t, nil, err)
+
+		// 共约3.5MB，翻滚3次，只保留最新的2个备份
+		line := strings.Repeat("a", 1000)
+		for i := 0; i < 3500; i++ {
+			l.Info(line)
+		}
+		l.Sync()
+
+		fi, err := os.Stat(filename)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, fi.Size() <= 1024*1024)
+
+		backups := listDir(t, dir)
+		assert.Equal(t, 3, len(backups), strings.Join(backups, ","))
+		for _, name := range backups[1:] {
+			assert.Equal(t, true, strings.HasPrefix(name, "size.log."), name)
+			assert.Equal(t, true, strings.HasSuffix(name, ".gz"), name)
+
+			f, err := os.Open(filepath.Join(dir, name))
+			assert.Equal(t, nil, err)
+			gr, err := gzip.NewReader(f)
+			assert.Equal(t, nil, err)
+			b, err := ioutil.ReadAll(gr)
+			assert.Equal(t, nil, err)
+			_ = f.Close()
+			assert.Equal(t, true, len(b) <= 1024*1024)
+			assert.Equal(t, true, len(b) > 1000*1024)
+			assert.Equal(t, true, strings.Contains(string(b), line))
+		}
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestRotate_Sweep(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nazalogtest")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+
+	old := time.Now().AddDate(0, 0, -40)
+	for _, name := range []string{"app.log.20200101", "app.log.20200102.gz", "app.log.bak", "other.log.20200101"} {
+		assert.Equal(t, nil, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0666))
+		assert.Equal(t, nil, os.Chtimes(filepath.Join(dir, name), old, old))
+	}
+	for i, name := range []string{"app.log.2099010100", "app.log.20990101000000.1.gz"} {
+		assert.Equal(t, nil, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0666))
+		mtime := time.Now().Add(-time.Duration(i) * time.Hour)
+		assert.Equal(t, nil, os.Chtimes(filepath.Join(dir, name), mtime, mtime))
+	}
+
+	all := []string{"app.log", "app.log.20200101", "app.log.20200102.gz", "app.log.2099010100", "app.log.20990101000000.1.gz", "app.log.bak", "other.log.20200101"}
+
+	// 没有开启翻滚时，不清理
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Filename = filename
+		option.IsToStdout = false
+		option.MaxRotateDays = 30
+	})
+	assert.Equal(t, nil, err)
+	l.Sync()
+	assert.Equal(t, all, listDir(t, dir))
+
+	// 启动时清理超过30天的备份文件，不是备份文件的不删除
+	err = l.Init(func(option *nazalog.Option) {
+		option.Filename = filename
+		option.IsToStdout = false
+		option.IsRotateDaily = true
+		option.MaxRotateDays = 30
+	})
+	assert.Equal(t, nil, err)
+	l.Sync()
+	assert.Equal(t, []string{"app.log", "app.log.2099010100", "app.log.20990101000000.1.gz", "app.log.bak", "other.log.20200101"}, listDir(t, dir))
+
+	// 按个数清理
+	err = l.Init(func(option *nazalog.Option) {
+		option.Filename = filename
+		option.IsToStdout = false
+		option.IsRotateDaily = true
+		option.MaxBackups = 1
+	})
+	assert.Equal(t, nil, err)
+	l.Sync()
+	assert.Equal(t, []string{"app.log", "app.log.2099010100", "app.log.bak", "other.log.20200101"}, listDir(t, dir))
+
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.MaxBackups = -1
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+}
+
+func TestRotate_NotOverwrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nazalogtest")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+
+	now := time.Now()
+	backupName := filename + "." + now.Format("2006010215")
+	assert.Equal(t, nil, ioutil.WriteFile(backupName, []byte("old"), 0666))
+
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Filename = filename
+		option.IsToStdout = false
+		option.IsRotateHourly = true
+	})
+	assert.Equal(t, nil, err)
+	l.Info("1")
+
+	nazalog.Clock = mock.NewFakeClock()
+	defer func() {
+		nazalog.Clock = mock.NewStdClock()
+	}()
+	nazalog.Clock.Set(now.Add(time.Hour))
+	l.Info("2")
+	l.Sync()
+
+	// 已存在的备份文件不会被覆盖
+	b, err := ioutil.ReadFile(backupName)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "old", string(b))
+	b, err = ioutil.ReadFile(backupName + ".1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, strings.Contains(string(b), " 1 - "))
+}
+
+func TestRotate_Clock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nazalogtest")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.log")
+
+	// 打开文件时的翻滚时间也使用 Clock
+	nazalog.Clock = mock.NewFakeClock()
+	defer func() {
+		nazalog.Clock = mock.NewStdClock()
+	}()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local)
+	nazalog.Clock.Set(now)
+
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Filename = filename
+		option.IsToStdout = false
+		option.IsRotateHourly = true
+	})
+	assert.Equal(t, nil, err)
+	l.Info("1")
+	nazalog.Clock.Set(now.Add(time.Hour))
+	l.Info("2")
+	l.Sync()
+
+	assert.Equal(t, []string{"app.log", "app.log.2020010203"}, listDir(t, dir))
+}
+
+func listDir(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	assert.Equal(t, nil, err)
+	var names []string
+	for _, fi := range infos {
+		names = append(names, fi.Name())
+	}
+	sort.Strings(names)
+	return names
+}
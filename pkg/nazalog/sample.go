@@ -0,0 +1,223 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sampleSuppressedMsg 周期结束时，输出被丢弃条数的日志内容
+const sampleSuppressedMsg = "nazalog: logs suppressed by sampling"
+
+// sampleSweepInterval 最多每隔多久检查一次所有已过期的采样周期
+const sampleSweepInterval = time.Second
+
+// sampleMaxWindows 最多同时记录多少个采样计数
+//
+// 比如 LogFirstN 的周期无限长，如果日志模板是动态的，计数会越来越多，
+// 超过上限时，先删除已过期的，如果依然超过，则清空所有计数（被丢弃的条数会先输出）
+const sampleMaxWindows = 4096
+
+// sampleRule 采样规则，每`interval`时间内，先输出前`first`条，之后每`thereafter`条输出一条
+//
+// `interval`为0表示周期无限长
+type sampleRule struct {
+	interval   time.Duration
+	first      int
+	thereafter int
+}
+
+// sampleKey 按规则、级别、日志模板区分独立计数
+//
+// 规则按值比较，使得每次调用 LogEvery 生成的Logger对象共享计数
+type sampleKey struct {
+	rule     sampleRule
+	level    Level
+	template string
+}
+
+type sampleWindow struct {
+	l          *logger // 用于输出被丢弃的条数，使用第一次打印该日志的Logger对象的前缀
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+type sampler struct {
+	mu        sync.Mutex
+	windows   map[sampleKey]*sampleWindow
+	lastSweep time.Time
+}
+
+// sampleReport 被丢弃的条数，在释放锁之后输出
+type sampleReport struct {
+	l          *logger
+	level      Level
+	template   string
+	suppressed int
+}
+
+func (l *logger) LogEvery(d time.Duration) Logger {
+	return l.withSampleRule(&sampleRule{interval: d, first: 1})
+}
+
+func (l *logger) LogFirstN(n int) Logger {
+	return l.withSampleRule(&sampleRule{first: n})
+}
+
+func (l *logger) withSampleRule(rule *sampleRule) Logger {
+	ll := &logger{
+		prefixs: l.prefixs,
+		fields:  l.fields,
+		rule:    rule,
+		core:    l.core,
+	}
+	return ll
+}
+
+func (r *sampleRule) allowN(count int) bool {
+	if count <= r.first {
+		return true
+	}
+	return r.thereafter > 0 && (count-r.first)%r.thereafter == 0
+}
+
+func newSampleRule(option Option) *sampleRule {
+	if option.SampleIntervalMs <= 0 {
+		return nil
+	}
+	return &sampleRule{
+		interval:   time.Duration(option.SampleIntervalMs) * time.Millisecond,
+		first:      option.SampleFirst,
+		thereafter: option.SampleThereafter,
+	}
+}
+
+// allow 级别为`level`，模板为`template`的日志是否需要输出
+//
+// 如果有采样周期结束，先输出该周期被丢弃的条数
+func (l *logger) allow(level Level, template string, calldepth int) bool {
+	if !l.isEnabled(level) {
+		return false
+	}
+	rule := l.rule
+	if rule == nil {
+		rule = l.core.sampleRule
+	}
+	if rule == nil || level >= LevelFatal {
+		return true
+	}
+
+	now := Clock.Now()
+	s := &l.core.sampler
+	var reports []sampleReport
+
+	s.mu.Lock()
+	if s.windows == nil {
+		s.windows = make(map[sampleKey]*sampleWindow)
+	}
+	key := sampleKey{rule: *rule, level: level, template: template}
+	w, ok := s.windows[key]
+	if !ok {
+		if len(s.windows) >= sampleMaxWindows {
+			s.lastSweep = now
+			reports = s.evictWithLock(now, reports)
+		}
+		w = &sampleWindow{l: l, start: now}
+		s.windows[key] = w
+	} else if rule.interval > 0 && now.Sub(w.start) >= rule.interval {
+		if w.suppressed > 0 {
+			reports = append(reports, sampleReport{l: w.l, level: level, template: template, suppressed: w.suppressed})
+		}
+		w.start = now
+		w.count = 0
+		w.suppressed = 0
+	}
+	w.count++
+	ret := rule.allowN(w.count)
+	if !ret {
+		w.suppressed++
+	}
+	if now.Sub(s.lastSweep) >= sampleSweepInterval {
+		s.lastSweep = now
+		reports = s.sweepWithLock(now, reports)
+	}
+	s.mu.Unlock()
+
+	for _, r := range reports {
+		r.output(calldepth + 1)
+	}
+	return ret
+}
+
+// flushSampled 输出所有还没输出的被丢弃的条数，在 Sync 时调用
+func (l *logger) flushSampled(calldepth int) {
+	s := &l.core.sampler
+	var reports []sampleReport
+
+	s.mu.Lock()
+	for key, w := range s.windows {
+		if w.suppressed > 0 {
+			reports = append(reports, sampleReport{l: w.l, level: key.level, template: key.template, suppressed: w.suppressed})
+			w.suppressed = 0
+		}
+	}
+	s.mu.Unlock()
+
+	for _, r := range reports {
+		r.output(calldepth + 1)
+	}
+}
+
+// reset 清空所有计数，未输出的被丢弃条数也一并丢弃
+func (s *sampler) reset() {
+	s.mu.Lock()
+	s.windows = nil
+	s.mu.Unlock()
+}
+
+// sweepWithLock 删除已过期的采样周期，避免不再打印的日志模板一直占用内存，并返回这些周期被丢弃的条数
+//
+// 周期无限长的不会被删除
+func (s *sampler) sweepWithLock(now time.Time, reports []sampleReport) []sampleReport {
+	for key, w := range s.windows {
+		if key.rule.interval <= 0 || now.Sub(w.start) < key.rule.interval {
+			continue
+		}
+		if w.suppressed > 0 {
+			reports = append(reports, sampleReport{l: w.l, level: key.level, template: key.template, suppressed: w.suppressed})
+		}
+		delete(s.windows, key)
+	}
+	return reports
+}
+
+// evictWithLock 计数个数达到 sampleMaxWindows 时调用，见 sampleMaxWindows
+func (s *sampler) evictWithLock(now time.Time, reports []sampleReport) []sampleReport {
+	reports = s.sweepWithLock(now, reports)
+	if len(s.windows) < sampleMaxWindows {
+		return reports
+	}
+	for key, w := range s.windows {
+		if w.suppressed > 0 {
+			reports = append(reports, sampleReport{l: w.l, level: key.level, template: key.template, suppressed: w.suppressed})
+		}
+	}
+	s.windows = make(map[sampleKey]*sampleWindow)
+	return reports
+}
+
+func (r *sampleReport) output(calldepth int) {
+	if !r.l.isEnabled(r.level) {
+		return
+	}
+	r.l.output(context.Background(), r.level, calldepth+1, sampleSuppressedMsg, []interface{}{"template", r.template, "suppressed", r.suppressed})
+}
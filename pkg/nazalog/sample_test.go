@@ -0,0 +1,126 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/mock"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func TestSample(t *testing.T) {
+	nazalog.Clock = mock.NewFakeClock()
+	defer func() {
+		nazalog.Clock = mock.NewStdClock()
+	}()
+	nazalog.Clock.Set(time.Unix(1600000000, 0))
+
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatText, &lines, func(option *nazalog.Option) {
+		option.LevelFlag = false
+		option.SampleIntervalMs = 1000
+		option.SampleFirst = 2
+		option.SampleThereafter = 3
+	})
+
+	// 前2条输出，之后每3条输出1条
+	for i := 0; i < 10; i++ {
+		l.Infof("seq=%d", i)
+	}
+	// 不同的模板独立计数
+	l.Infof("other")
+	assert.Equal(t, []string{"seq=0\n", "seq=1\n", "seq=4\n", "seq=7\n", "other\n"}, lines)
+
+	// 周期结束后，先输出被丢弃的条数
+	lines = nil
+	nazalog.Clock.Add(time.Second)
+	l.Infof("seq=%d", 10)
+	assert.Equal(t, []string{
+		"nazalog: logs suppressed by sampling template=\"seq=%d\" suppressed=6\n",
+		"seq=10\n",
+	}, lines)
+
+	// 不输出的级别不计数
+	lines = nil
+	l.SetLevel(nazalog.LevelWarn)
+	l.Infof("seq=%d", 11)
+	l.SetLevel(nazalog.LevelInfo)
+	l.Infof("seq=%d", 12)
+	assert.Equal(t, []string{"seq=12\n"}, lines)
+
+	_, err := nazalog.New(func(option *nazalog.Option) {
+		option.SampleThereafter = -1
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+}
+
+func TestLogEvery(t *testing.T) {
+	nazalog.Clock = mock.NewFakeClock()
+	defer func() {
+		nazalog.Clock = mock.NewStdClock()
+	}()
+	nazalog.Clock.Set(time.Unix(1600000000, 0))
+
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatText, &lines, func(option *nazalog.Option) {
+		option.LevelFlag = false
+	})
+
+	// 每次调用 LogEvery 生成的Logger对象共享计数
+	for i := 0; i < 5; i++ {
+		l.WithPrefix("rtp").LogEvery(time.Second).Warnw("packet lost", "seq", i)
+		nazalog.Clock.Add(300 * time.Millisecond)
+	}
+	// 没有采样的Logger对象不受影响
+	l.Warnw("packet lost", "seq", 5)
+	assert.Equal(t, []string{
+		"[rtp] packet lost seq=0\n",
+		"[rtp] nazalog: logs suppressed by sampling template=\"packet lost\" suppressed=3\n",
+		"[rtp] packet lost seq=4\n",
+		"packet lost seq=5\n",
+	}, lines)
+
+	// Sync 时输出还没输出的被丢弃条数
+	lines = nil
+	l.LogFirstN(2).Error("a")
+	l.LogFirstN(2).Error("a")
+	l.LogFirstN(2).Error("a")
+	l.Sync()
+	l.Sync()
+	assert.Equal(t, []string{
+		"a\n",
+		"a\n",
+		"nazalog: logs suppressed by sampling template=a suppressed=1\n",
+	}, lines)
+}
+
+func TestLogFirstN_Evict(t *testing.T) {
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatText, &lines, func(option *nazalog.Option) {
+		option.LevelFlag = false
+	})
+
+	l.LogFirstN(1).Infof("a=%d", 1)
+	l.LogFirstN(1).Infof("a=%d", 2)
+	// 动态的日志内容，每条日志单独计数，计数个数达到上限后被清空
+	for i := 0; i < 4096; i++ {
+		l.LogFirstN(1).Info("dynamic ", i)
+	}
+	l.LogFirstN(1).Infof("a=%d", 3)
+	assert.Equal(t, 1+4096+2, len(lines))
+	assert.Equal(t, []string{
+		"dynamic 4094\n",
+		"nazalog: logs suppressed by sampling template=\"a=%d\" suppressed=1\n",
+		"dynamic 4095\n",
+		"a=3\n",
+	}, lines[4095:])
+}
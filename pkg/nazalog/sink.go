@@ -0,0 +1,189 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+)
+
+// SinkOption 额外的日志输出目标，见 Option.Sinks
+//
+// Filename 、 Writer 和 Memory 必须且只能设置一个
+type SinkOption struct {
+	Level  Level  `json:"level"`  // 大于等于该级别的日志才会输出至该目标，注意，日志首先需要满足 Option.Level
+	Format Format `json:"format"` // 为0则和 Option.Format 相同。注意，不使用彩色
+
+	Filename string    `json:"filename"` // 输出至日志文件，翻滚、压缩、清理等配置和 Option 中的相同
+	Writer   io.Writer `json:"-"`        // 输出至用户提供的目标，比如 os.Stderr 。如果实现了 LevelWriter ，则调用 WriteLevel
+
+	Memory *MemorySink `json:"-"` // 保存在内存中，用于查询最近的日志，见 NewMemorySink
+}
+
+// LevelWriter 需要根据日志级别做不同处理的输出目标（比如syslog）可以实现该接口
+//
+// 异步模式下，普通 io.Writer 会合并多条日志后调用一次 Write ，而 LevelWriter 每条日志调用一次 WriteLevel
+type LevelWriter interface {
+	WriteLevel(level Level, b []byte) (n int, err error)
+}
+
+// sink 日志输出目标，包括 Option.IsToStdout 、 Option.Filename 对应的目标，以及 Option.Sinks
+type sink struct {
+	level  Level
+	format Format // 为0表示使用主格式，也即 Option.Format ，并且输出至控制台时使用彩色
+
+	w      io.Writer
+	file   *rotateFile
+	memory *MemorySink
+
+	batch     bytes.Buffer // 异步模式下，合并多条日志
+	batchTime time.Time    // batch 中最后一条日志的时间
+}
+
+// formattedLine 一条日志按各输出目标需要的格式格式化后的结果
+type formattedLine struct {
+	main    bytes.Buffer
+	bufs    [FormatLogfmt]bytes.Buffer // 下标为 Format-1
+	hasBufs [FormatLogfmt]bool
+	prefixs []string // 打印该日志的Logger对象的前缀，只读
+}
+
+func (fl *formattedLine) reset() {
+	fl.main.Reset()
+	for i := range fl.bufs {
+		fl.bufs[i].Reset()
+		fl.hasBufs[i] = false
+	}
+	fl.prefixs = nil
+}
+
+func (fl *formattedLine) bytes(s *sink) []byte {
+	if s.format == 0 {
+		return fl.main.Bytes()
+	}
+	return fl.bufs[s.format-1].Bytes()
+}
+
+// newSinks 根据配置创建所有输出目标
+func newSinks(c *core, option Option) ([]*sink, error) {
+	var sinks []*sink
+	closeAll := func() {
+		for _, s := range sinks {
+			s.close()
+		}
+	}
+
+	if option.IsToStdout {
+		sinks = append(sinks, &sink{w: os.Stdout})
+	}
+	if option.Filename != "" {
+		f, err := openRotateFile(c, option)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, &sink{file: f})
+	}
+	for _, so := range option.Sinks {
+		s := &sink{
+			level:  so.Level,
+			format: so.Format,
+			w:      so.Writer,
+			memory: so.Memory,
+		}
+		if s.format == 0 {
+			s.format = option.Format
+		}
+		if so.Filename != "" {
+			fileOption := option
+			fileOption.Filename = so.Filename
+			f, err := openRotateFile(c, fileOption)
+			if err != nil {
+				closeAll()
+				return nil, err
+			}
+			s.file = f
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// write
+//
+// 注意，调用方需持有 core.m
+func (s *sink) write(level Level, now time.Time, fl *formattedLine) {
+	b := fl.bytes(s)
+	if s.memory != nil {
+		s.memory.add(level, now, fl.prefixs, b)
+	} else if s.file != nil {
+		_ = s.file.write(now, b)
+	} else if lw, ok := s.w.(LevelWriter); ok {
+		_, _ = lw.WriteLevel(level, b)
+	} else {
+		_, _ = s.w.Write(b)
+	}
+	if level == LevelFatal || level == LevelPanic {
+		s.sync()
+	}
+}
+
+// appendBatch 异步模式下，将日志追加到待合并写入的缓冲中
+//
+// 注意，调用方需持有 core.m
+func (s *sink) appendBatch(level Level, now time.Time, fl *formattedLine) {
+	b := fl.bytes(s)
+	if s.memory != nil {
+		s.memory.add(level, now, fl.prefixs, b)
+		return
+	} else if s.file != nil {
+		// 需要翻滚日志文件时，先把属于上个周期的日志写入老文件
+		if s.file.needRotate(now, s.batch.Len(), len(b)) {
+			s.flushBatch()
+			_ = s.file.rotateIfNeeded(now, len(b))
+		}
+	} else if lw, ok := s.w.(LevelWriter); ok {
+		_, _ = lw.WriteLevel(level, b)
+		return
+	}
+	s.batch.Write(b)
+	s.batchTime = now
+}
+
+// flushBatch
+//
+// 注意，调用方需持有 core.m
+func (s *sink) flushBatch() {
+	if s.batch.Len() == 0 {
+		return
+	}
+	if s.file != nil {
+		_ = s.file.write(s.batchTime, s.batch.Bytes())
+	} else {
+		_, _ = s.w.Write(s.batch.Bytes())
+	}
+	s.batch.Reset()
+}
+
+func (s *sink) sync() {
+	if s.file != nil {
+		s.file.sync()
+		return
+	}
+	if syncer, ok := s.w.(interface{ Sync() error }); ok {
+		_ = syncer.Sync()
+	}
+}
+
+func (s *sink) close() {
+	if s.file != nil {
+		s.file.close()
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+package nazalog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+type levelWriter struct {
+	levels []nazalog.Level
+	lines  []string
+}
+
+func (w *levelWriter) Write(b []byte) (int, error) {
+	panic("should call WriteLevel")
+}
+
+func (w *levelWriter) WriteLevel(level nazalog.Level, b []byte) (int, error) {
+	w.levels = append(w.levels, level)
+	w.lines = append(w.lines, string(b))
+	return len(b), nil
+}
+
+func TestSinks(t *testing.T) {
+	for _, isAsync := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "nazalogtest")
+		assert.Equal(t, nil, err)
+
+		var jsonBuf bytes.Buffer
+		lw := &levelWriter{}
+		var hookLines []string
+		l, err := nazalog.New(func(option *nazalog.Option) {
+			option.Level = nazalog.LevelInfo
+			option.IsToStdout = false
+			option.Filename = filepath.Join(dir, "all.log")
+			option.IsAsync = isAsync
+			option.HookBackendOutFn = func(level nazalog.Level, line []byte) {
+				hookLines = append(hookLines, string(line))
+			}
+			option.Sinks = append(option.Sinks,
+				nazalog.SinkOption{Level: nazalog.LevelError, Filename: filepath.Join(dir, "sub", "error.log")},
+				nazalog.SinkOption{Level: nazalog.LevelWarn, Format: nazalog.FormatJson, Writer: &jsonBuf},
+				nazalog.SinkOption{Writer: lw},
+			)
+		})
+		assert.Equal(t, nil, err)
+
+		l.Debug("debug")
+		l.Info("info")
+		l.Warnw("warn", "k", 1)
+		l.Error("error")
+		l.Sync()
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, "all.log"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 3, strings.Count(string(b), "\n"))
+		assert.Equal(t, false, strings.Contains(string(b), "debug"))
+
+		b, err = ioutil.ReadFile(filepath.Join(dir, "sub", "error.log"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, strings.Count(string(b), "\n"))
+		assert.Equal(t, true, strings.Contains(string(b), "ERROR error - sink_test.go:"))
+
+		jsonLines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+		assert.Equal(t, 2, len(jsonLines))
+		var m map[string]interface{}
+		assert.Equal(t, nil, json.Unmarshal([]byte(jsonLines[0]), &m))
+		assert.Equal(t, "WARN", m["level"])
+		assert.Equal(t, float64(1), m["k"])
+
+		assert.Equal(t, []nazalog.Level{nazalog.LevelInfo, nazalog.LevelWarn, nazalog.LevelError}, lw.levels)
+		assert.Equal(t, true, strings.Contains(lw.lines[1], " WARN warn k=1 - sink_test.go:"))
+		assert.Equal(t, 3, len(hookLines))
+
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestSinks_Validate(t *testing.T) {
+	_, err := nazalog.New(func(option *nazalog.Option) {
+		option.Sinks = []nazalog.SinkOption{{}}
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.Sinks = []nazalog.SinkOption{{Filename: "/tmp/nazalogtest/x.log", Writer: os.Stderr}}
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.Sinks = []nazalog.SinkOption{{Writer: os.Stderr, Format: nazalog.FormatLogfmt + 1}}
+	})
+	assert.Equal(t, nazalog.ErrLog, err)
+	_, err = nazalog.New(func(option *nazalog.Option) {
+		option.Sinks = []nazalog.SinkOption{{Filename: "./log_test.go/111"}}
+	})
+	assert.IsNotNil(t, err)
+
+	l, err := nazalog.New(func(option *nazalog.Option) {
+		option.Sinks = []nazalog.SinkOption{{Writer: os.Stderr, Format: nazalog.FormatLogfmt}}
+	})
+	assert.Equal(t, nil, err)
+	l.Info("to stdout and stderr")
+}
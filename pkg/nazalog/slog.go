@@ -0,0 +1,190 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+//go:build go1.21
+// +build go1.21
+
+package nazalog
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// NewSlogHandler 创建一个 slog.Handler ，日志交给`l`输出
+//
+// 使用`l`的日志级别、前缀、采样、输出目标、翻滚等配置，源码文件及行号、时间使用 slog.Record 中的值。
+// slog的分组（ slog.Logger.WithGroup ）会展开为以"."连接的键，比如"req.method"。
+// 大于等于 slog.LevelError 的日志都以 LevelError 输出，不会退出程序或者panic。
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// NewSlogLogger 创建一个Logger对象，日志交给`h`输出
+//
+// Logger对象的日志级别、前缀级别、采样、ShortFileFlag、AssertBehavior 等配置依然有效，
+// 和输出相关的配置（输出目标、格式、翻滚、异步等）不再生效。
+// 前缀以键为"prefix"的属性输出，多个前缀以","连接；With 添加的键值对作为属性输出。
+// Fatal、Panic级别分别以 slog.LevelError+4 、 slog.LevelError+8 输出。
+func NewSlogLogger(h slog.Handler, modOptions ...ModOption) (Logger, error) {
+	modOptions = append(modOptions, func(option *Option) {
+		option.Filename = ""
+		option.IsToStdout = false
+		option.Sinks = nil
+		option.IsAsync = false
+	})
+	l, err := newLogger(modOptions...)
+	if err != nil {
+		return nil, err
+	}
+	l.core.backend = slogBackend{h: h}
+	return l, nil
+}
+
+// LevelToSlog 将nazalog的日志级别转换为slog的日志级别
+func LevelToSlog(level Level) slog.Level {
+	switch level {
+	case LevelTrace:
+		return slog.LevelDebug - 4
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	case LevelFatal:
+		return slog.LevelError + 4
+	}
+	return slog.LevelError + 8
+}
+
+// LevelFromSlog 将slog的日志级别转换为nazalog的日志级别，大于等于 slog.LevelError 的都转换为 LevelError
+func LevelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	}
+	return LevelError
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type slogHandler struct {
+	l     Logger
+	attrs []interface{} // WithAttrs 添加的属性，已展开为键值对
+	group string        // WithGroup 添加的分组，比如"req."
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if l, ok := h.l.(*logger); ok {
+		return l.isEnabled(LevelFromSlog(level))
+	}
+	return true
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	level := LevelFromSlog(r.Level)
+	kv := make([]interface{}, 0, len(h.attrs)+2*r.NumAttrs())
+	kv = append(kv, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		kv = appendSlogAttr(kv, h.group, a)
+		return true
+	})
+
+	l, ok := h.l.(*logger)
+	if !ok {
+		// 调用栈为 用户代码 -> slog.Logger.Info 等 -> slog.Logger.log -> Handle
+		h.l.Outw(level, 4, r.Message, kv...)
+		return nil
+	}
+	if !l.allow(level, r.Message, 1) {
+		return nil
+	}
+	now := r.Time
+	if now.IsZero() {
+		now = Clock.Now()
+	}
+	l.outputAt(ctx, level, now, r.PC, r.Message, kv)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	hh := *h
+	hh.attrs = make([]interface{}, 0, len(h.attrs)+2*len(attrs))
+	hh.attrs = append(hh.attrs, h.attrs...)
+	for _, a := range attrs {
+		hh.attrs = appendSlogAttr(hh.attrs, h.group, a)
+	}
+	return &hh
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	hh := *h
+	hh.group = h.group + name + "."
+	return &hh
+}
+
+// appendSlogAttr 将`a`展开为键值对添加到`kv`尾部，规则同 slog.Handler 的约定：
+// 忽略键和值都为零值的属性；忽略没有属性的分组；键为空的分组，其属性不加分组前缀
+func appendSlogAttr(kv []interface{}, group string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Equal(slog.Attr{}) {
+			return kv
+		}
+		return append(kv, group+a.Key, a.Value.Any())
+	}
+
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return kv
+	}
+	if a.Key != "" {
+		group = group + a.Key + "."
+	}
+	for _, ga := range attrs {
+		kv = appendSlogAttr(kv, group, ga)
+	}
+	return kv
+}
+
+// ---------------------------------------------------------------------------------------------------------------------
+
+type slogBackend struct {
+	h slog.Handler
+}
+
+func (b slogBackend) handle(ctx context.Context, l *logger, level Level, now time.Time, pc uintptr, msg string, kv []interface{}) {
+	sl := LevelToSlog(level)
+	if !b.h.Enabled(ctx, sl) {
+		return
+	}
+	r := slog.NewRecord(now, sl, msg, pc)
+	if len(l.prefixs) != 0 {
+		r.AddAttrs(slog.String(keyPrefix, strings.Join(l.prefixs, ",")))
+	}
+	r.Add(l.fields...)
+	r.Add(kv...)
+	_ = b.h.Handle(ctx, r)
+}
@@ -0,0 +1,101 @@
+// Copyright 2026, Chef.  All rights reserved.
+// https://github.com/q191201771/naza
+//
+// Use of this source code is governed by a MIT-style license
+// that can be found in the License file.
+//
+// Author: Chef (191201771@qq.com)
+
+//go:build go1.21
+// +build go1.21
+
+package nazalog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/q191201771/naza/pkg/assert"
+	"github.com/q191201771/naza/pkg/nazalog"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var lines []string
+	l := newCaptureLogger(t, nazalog.FormatLogfmt, &lines, func(option *nazalog.Option) {
+		option.Level = nazalog.LevelInfo
+		option.ShortFileFlag = true
+	})
+
+	sl := slog.New(nazalog.NewSlogHandler(l.WithPrefix("rtmp")))
+	assert.Equal(t, false, sl.Enabled(context.Background(), slog.LevelDebug))
+	sl.Debug("ignored")
+	sl.With("conn", 1).WithGroup("req").Info("hello", "method", "GET", slog.Group("h", "a", 1), slog.Group("empty"))
+	sl.Log(context.Background(), slog.LevelError+4, "not fatal")
+
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, true, strings.HasPrefix(lines[0], "level=INFO prefix=rtmp msg=hello conn=1 req.method=GET req.h.a=1 caller=slog_test.go:"), lines[0])
+	assert.Equal(t, true, strings.HasPrefix(lines[1], "level=ERROR prefix=rtmp msg=\"not fatal\" caller=slog_test.go:"), lines[1])
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug - 4,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l, err := nazalog.NewSlogLogger(h, func(option *nazalog.Option) {
+		option.Level = nazalog.LevelTrace
+		option.PrefixLevels = map[string]nazalog.Level{"quiet": nazalog.LevelError}
+	})
+	assert.Equal(t, nil, err)
+
+	l.WithPrefix("rtmp").With("conn", 1).Infow("hi", "x", 2)
+	l.Tracef("seq=%d", 1)
+	l.WithPrefix("quiet").Warn("ignored")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines), buf.String())
+	assert.Equal(t, true, strings.HasPrefix(lines[0], "level=INFO source="), lines[0])
+	assert.Equal(t, true, strings.Contains(lines[0], "/slog_test.go:"), lines[0])
+	assert.Equal(t, true, strings.HasSuffix(lines[0], " msg=hi prefix=rtmp conn=1 x=2"), lines[0])
+	assert.Equal(t, true, strings.HasSuffix(lines[1], " msg=\"seq=1\""), lines[1])
+	assert.Equal(t, true, strings.HasPrefix(lines[1], "level=DEBUG-4 "), lines[1])
+}
+
+type ctxKey struct{}
+
+// ctxHandler 从`ctx`中获取追踪信息的 slog.Handler
+type ctxHandler struct {
+	slog.Handler
+	traces *[]string
+}
+
+func (h ctxHandler) Handle(ctx context.Context, r slog.Record) error {
+	v, _ := ctx.Value(ctxKey{}).(string)
+	*h.traces = append(*h.traces, v)
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestSlogLogger_Context(t *testing.T) {
+	var buf bytes.Buffer
+	var traces []string
+	h := ctxHandler{Handler: slog.NewTextHandler(&buf, nil), traces: &traces}
+	l, err := nazalog.NewSlogLogger(h)
+	assert.Equal(t, nil, err)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "span1")
+	ctx = nazalog.ContextWithFields(ctx, "k", "v")
+	l.Infoc(ctx, "with ctx")
+	l.Info("without ctx")
+	assert.Equal(t, []string{"span1", ""}, traces)
+	assert.Equal(t, true, strings.Contains(buf.String(), "msg=\"with ctx\" k=v\n"), buf.String())
+}
@@ -47,13 +47,21 @@ func NewSliceBytePool(strategy Strategy) SliceBytePool {
 	switch strategy {
 	case StrategyMultiStdPoolBucket:
 		capToFreeBucket = make(map[int]Bucket)
-		for i := minSize; i <= maxSize; i <<= 1 {
+		// 注意，不能用 i <= maxSize 作为循环条件，32位平台下 maxSize 再左移一位会溢出，导致死循环
+		for i := minSize; ; i <<= 1 {
 			capToFreeBucket[i] = NewStdPoolBucket()
+			if i >= maxSize {
+				break
+			}
 		}
 	case StrategyMultiSlicePoolBucket:
 		capToFreeBucket = make(map[int]Bucket)
-		for i := minSize; i <= maxSize; i <<= 1 {
+		// 注意，不能用 i <= maxSize 作为循环条件，32位平台下 maxSize 再左移一位会溢出，导致死循环
+		for i := minSize; ; i <<= 1 {
 			capToFreeBucket[i] = NewSliceBucket()
+			if i >= maxSize {
+				break
+			}
 		}
 	}
 